@@ -0,0 +1,121 @@
+package sql_ast
+
+// the lists have to be updated with every new node type
+
+var (
+	_ SqlStmt = (*AlterPolicyStmt)(nil)
+	_ SqlStmt = (*AlterStmt)(nil)
+	_ SqlStmt = (*BeginStmt)(nil)
+	_ SqlStmt = (*CommitStmt)(nil)
+	_ SqlStmt = (*CreateIndexStmt)(nil)
+	_ SqlStmt = (*CreatePolicyStmt)(nil)
+	_ SqlStmt = (*CreatePublicationStmt)(nil)
+	_ SqlStmt = (*CreateRuleStmt)(nil)
+	_ SqlStmt = (*CreateStmt)(nil)
+	_ SqlStmt = (*CreateSubscriptionStmt)(nil)
+	_ SqlStmt = (*DoStmt)(nil)
+	_ SqlStmt = (*DropFunctionStmt)(nil)
+	_ SqlStmt = (*DropPolicyStmt)(nil)
+	_ SqlStmt = (*DropStmt)(nil)
+	_ SqlStmt = (*GrantStmt)(nil)
+	_ SqlStmt = (*InsertStmt)(nil)
+	_ SqlStmt = (*NopStmt)(nil)
+	_ SqlStmt = (*RawStmt)(nil)
+	_ SqlStmt = (*SelectStmt)(nil)
+	_ SqlStmt = (*UpdateStmt)(nil)
+	_ SqlStmt = (*WithStmt)(nil)
+)
+
+var (
+	_ SqlExpr = (*AddColumnExpr)(nil)
+	_ SqlExpr = (*AddExpr)(nil)
+	_ SqlExpr = (*AddValueExpr)(nil)
+	_ SqlExpr = (*AliasExpr)(nil)
+	_ SqlExpr = (*AllColumnsExpr)(nil)
+	_ SqlExpr = (*AlterAttributeExpr)(nil)
+	_ SqlExpr = (*AlterColumnTypeExpr)(nil)
+	_ SqlExpr = (*AlterExpr)(nil)
+	_ SqlExpr = (*BinaryExpr)(nil)
+	_ SqlExpr = (*BracketBlock)(nil)
+	_ SqlExpr = (*CastExpr)(nil)
+	_ SqlExpr = (*ClusterOnExpr)(nil)
+	_ SqlExpr = (*CollationDescription)(nil)
+	_ SqlExpr = (*ColumnRef)(nil)
+	_ SqlExpr = (*ConstraintWithColumns)(nil)
+	_ SqlExpr = (*DataTypeExpr)(nil)
+	_ SqlExpr = (*Default)(nil)
+	_ SqlExpr = (*DomainDescription)(nil)
+	_ SqlExpr = (*DropColumnExpr)(nil)
+	_ SqlExpr = (*DropExpr)(nil)
+	_ SqlExpr = (*EnumDescription)(nil)
+	_ SqlExpr = (*False)(nil)
+	_ SqlExpr = (*FieldSelectExpr)(nil)
+	_ SqlExpr = (*FncCall)(nil)
+	_ SqlExpr = (*FrameBound)(nil)
+	_ SqlExpr = (*FrameClause)(nil)
+	_ SqlExpr = (*FunctionDescription)(nil)
+	_ SqlExpr = (*FunctionParam)(nil)
+	_ SqlExpr = (*Integer)(nil)
+	_ SqlExpr = (*IntervalLiteral)(nil)
+	_ SqlExpr = (*Literal)(nil)
+	_ SqlExpr = (*NamedConstraintExpr)(nil)
+	_ SqlExpr = (*NotExpr)(nil)
+	_ SqlExpr = (*NotNullClause)(nil)
+	_ SqlExpr = (*OrderByClause)(nil)
+	_ SqlExpr = (*OverExpr)(nil)
+	_ SqlExpr = (*OverlapsExpr)(nil)
+	_ SqlExpr = (*ParameterExpr)(nil)
+	_ SqlExpr = (*PartitionClause)(nil)
+	_ SqlExpr = (*PartitionHashBound)(nil)
+	_ SqlExpr = (*PartitionListBound)(nil)
+	_ SqlExpr = (*PartitionOfClause)(nil)
+	_ SqlExpr = (*PartitionRangeBound)(nil)
+	_ SqlExpr = (*RecordDescription)(nil)
+	_ SqlExpr = (*RowConstructorExpr)(nil)
+	_ SqlExpr = (*RowLevelSecurityExpr)(nil)
+	_ SqlExpr = (*SchemaExpr)(nil)
+	_ SqlExpr = (*Selector)(nil)
+	_ SqlExpr = (*SetDropExpr)(nil)
+	_ SqlExpr = (*SetExpr)(nil)
+	_ SqlExpr = (*SqlField)(nil)
+	_ SqlExpr = (*SqlRename)(nil)
+	_ SqlExpr = (*StorageExpr)(nil)
+	_ SqlExpr = (*String)(nil)
+	_ SqlExpr = (*StringExpr)(nil)
+	_ SqlExpr = (*TableAllColumnsExpr)(nil)
+	_ SqlExpr = (*TableAsExpr)(nil)
+	_ SqlExpr = (*TableBodyDescriber)(nil)
+	_ SqlExpr = (*TablesampleClause)(nil)
+	_ SqlExpr = (*True)(nil)
+	_ SqlExpr = (*TypeCastExpr)(nil)
+	_ SqlExpr = (*UnaryExpr)(nil)
+	_ SqlExpr = (*UnnamedConstraintExpr)(nil)
+	_ SqlExpr = (*WindowSpec)(nil)
+	_ SqlExpr = (*WithoutClusterExpr)(nil)
+)
+
+var (
+	_ SqlIdent = (*Ident)(nil)
+	_ SqlIdent = (*Literal)(nil)
+	_ SqlIdent = (*Selector)(nil)
+	_ SqlIdent = (*WithoutNameIdent)(nil)
+)
+
+var (
+	_ ConstraintInterface = (*ConstraintCheckExpr)(nil)
+	_ ConstraintInterface = (*ConstraintDefaultExpr)(nil)
+	_ ConstraintInterface = (*ConstraintForeignKeyExpr)(nil)
+	_ ConstraintInterface = (*ConstraintGeneratedExpr)(nil)
+	_ ConstraintInterface = (*ConstraintIdentityExpr)(nil)
+	_ ConstraintInterface = (*ConstraintNullableExpr)(nil)
+	_ ConstraintInterface = (*ConstraintPrimaryKeyExpr)(nil)
+	_ ConstraintInterface = (*ConstraintUniqueExpr)(nil)
+	_ ConstraintInterface = (*ConstraintWithColumns)(nil)
+	_ ConstraintInterface = (*NamedConstraintExpr)(nil)
+	_ ConstraintInterface = (*UnnamedConstraintExpr)(nil)
+)
+
+var (
+	_ IndexKey = (*ColumnIndexKey)(nil)
+	_ IndexKey = (*ExprIndexKey)(nil)
+)
@@ -0,0 +1,92 @@
+package sql_ast
+
+import (
+	"fmt"
+	"go/token"
+	"testing"
+)
+
+func benchmarkTable(name string, columns int) *CreateStmt {
+	var (
+		length = 255
+		body   = &TableBodyDescriber{}
+	)
+	for i := 0; i < columns; i++ {
+		var field = &SqlField{Name: &Literal{Text: fmt.Sprintf("column_%d", i)}, Describer: &DataTypeExpr{DataType: "varchar", Length: &length}}
+		if i == 0 {
+			field.Describer = &DataTypeExpr{DataType: "bigint"}
+			field.Constraints = []ConstraintExpr{&UnnamedConstraintExpr{Constraint: &ConstraintPrimaryKeyExpr{}}}
+		} else if i%2 == 0 {
+			field.Constraints = []ConstraintExpr{&UnnamedConstraintExpr{Constraint: &ConstraintNullableExpr{Nullable: NullableNotNull}}}
+		}
+		body.AddField(field)
+	}
+	return &CreateStmt{Target: TargetTable, Name: &Selector{Container: "public", Name: name}, Create: body}
+}
+
+func benchmarkSelect(columns, joins int) SelectStmt {
+	var query = SelectStmt{From: Table("t0").WithAlias("a0")}
+	for i := 0; i < columns; i++ {
+		query.AddColumn(&ColumnRef{Table: &Literal{Text: fmt.Sprintf("a%d", i%(joins+1))}, Column: &Literal{Text: fmt.Sprintf("column_%d", i)}})
+	}
+	for i := 1; i <= joins; i++ {
+		var alias = fmt.Sprintf("a%d", i)
+		query.Joins = append(query.Joins, JoinClause{
+			Kind:  "left",
+			Table: Table(fmt.Sprintf("t%d", i)).WithAlias(alias),
+			On: &BinaryExpr{
+				Left:  &ColumnRef{Table: &Literal{Text: alias}, Column: &Literal{Text: "parent_id"}},
+				Right: &ColumnRef{Table: &Literal{Text: fmt.Sprintf("a%d", i-1)}, Column: &Literal{Text: "id"}},
+				Op:    token.EQL,
+			},
+		})
+	}
+	query.Where = &BinaryExpr{Left: &ColumnRef{Table: &Literal{Text: "a0"}, Column: &Literal{Text: "id"}}, Right: &ParameterExpr{Position: 1}, Op: token.EQL}
+	return query
+}
+
+func BenchmarkCreateStmt_String(b *testing.B) {
+	var stmt = benchmarkTable("wide", 100)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = stmt.String()
+	}
+}
+
+func BenchmarkSelectStmt_String(b *testing.B) {
+	var stmt = benchmarkSelect(50, 10)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = stmt.String()
+	}
+}
+
+func BenchmarkWithStmt_String(b *testing.B) {
+	var stmt = &WithStmt{Select: benchmarkSelect(20, 5)}
+	for i := 0; i < 5; i++ {
+		stmt.CTEs = append(stmt.CTEs, CommonTableExpr{Name: fmt.Sprintf("t%d", i), Query: benchmarkSelect(10, 2)})
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = stmt.String()
+	}
+}
+
+func BenchmarkStatementList_String(b *testing.B) {
+	var stmts = make(StatementList, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		var query = benchmarkSelect(5, 1)
+		switch i % 3 {
+		case 0:
+			stmts = append(stmts, benchmarkTable(fmt.Sprintf("t%d", i), 10))
+		case 1:
+			stmts = append(stmts, &query)
+		default:
+			stmts = append(stmts, &DropStmt{Target: TargetTable, Name: &Selector{Container: "public", Name: fmt.Sprintf("t%d", i)}, IfExists: true})
+		}
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = stmts.String()
+	}
+}
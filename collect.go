@@ -0,0 +1,116 @@
+package sql_ast
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CollectTables returns all the tables referenced in FROM clauses and as targets of INSERT and UPDATE statements.
+// The references to the common table expressions of the statement are not tables, see WithStmt.dependedOn
+func CollectTables(stmt SqlStmt) []NamedObject {
+	var (
+		result = make([]NamedObject, 0)
+		ctes   = collectCTEs(stmt)
+	)
+	Walk(stmt, func(node interface{}) bool {
+		if desc, ok := node.(*TableDesc); ok && desc.Table != nil {
+			if table := objectDependencies(desc.Table)[0]; table.Schema != "" || !ctes[table.Object] {
+				result = appendUnique(result, table)
+			}
+		}
+		return true
+	})
+	return result
+}
+
+// CollectColumns returns all the column references. Aliases are resolved to the tables,
+// unqualified columns are bound to the table if it is the only one in the statement
+func CollectColumns(stmt SqlStmt) []NamedObject {
+	var (
+		result = make([]NamedObject, 0)
+		tables = CollectTables(stmt)
+		scope  = make(map[string]SqlIdent)
+	)
+	Walk(stmt, func(node interface{}) bool {
+		if desc, ok := node.(*TableDesc); ok && desc.Table != nil && desc.Alias != "" {
+			scope[desc.Alias] = desc.Table
+		}
+		return true
+	})
+	var (
+		columnOf = func(table SqlIdent, column string) NamedObject {
+			if table == nil {
+				if len(tables) == 1 {
+					return NamedObject{Schema: tables[0].Schema, Object: tables[0].Object, Field: column}
+				}
+				return NamedObject{Field: column}
+			}
+			if aliased, ok := scope[fullName(table)]; ok {
+				table = aliased
+			}
+			var obj = objectDependencies(table)[0]
+			return NamedObject{Schema: obj.Schema, Object: obj.Object, Field: column}
+		}
+	)
+	Walk(stmt, func(node interface{}) bool {
+		switch n := node.(type) {
+		case *ColumnRef:
+			result = appendUnique(result, columnOf(n.Table, n.Column.GetName()))
+		case *InsertStmt:
+			for _, column := range n.Columns {
+				result = appendUnique(result, columnOf(n.Table.Table, column))
+			}
+		case *UpdateStmt:
+			for _, set := range n.Set {
+				if binary, ok := set.(*BinaryExpr); ok {
+					if literal, ok := binary.Left.(*Literal); ok {
+						result = appendUnique(result, columnOf(n.Table.Table, literal.GetName()))
+					}
+				}
+			}
+		}
+		return true
+	})
+	return result
+}
+
+func appendUnique(objects []NamedObject, obj NamedObject) []NamedObject {
+	for _, o := range objects {
+		if o == obj {
+			return objects
+		}
+	}
+	return append(objects, obj)
+}
+
+// CollectParameters returns the parameters in order of their first appearance, duplicates are omitted.
+// Positional parameters must be numbered without gaps, mixing them with named parameters is an error
+func CollectParameters(stmt SqlStmt) ([]ParameterExpr, error) {
+	var (
+		result    = make([]ParameterExpr, 0)
+		positions = make(map[int]bool)
+		names     = make(map[string]bool)
+	)
+	Walk(stmt, func(node interface{}) bool {
+		if param, ok := node.(*ParameterExpr); ok {
+			if param.Name != "" && !names[param.Name] {
+				names[param.Name] = true
+				result = append(result, *param)
+			}
+			if param.Name == "" && !positions[param.Position] {
+				positions[param.Position] = true
+				result = append(result, *param)
+			}
+		}
+		return true
+	})
+	if len(names) > 0 && len(positions) > 0 {
+		return nil, errors.New("positional and named parameters cannot be mixed")
+	}
+	for i := 1; i <= len(positions); i++ {
+		if !positions[i] {
+			return nil, fmt.Errorf("parameter $%d is missing", i)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,110 @@
+package sql_ast
+
+import (
+	"fmt"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestCollectTables(t *testing.T) {
+	var query = func(from TableDesc) SelectStmt {
+		return SelectStmt{Columns: []SqlExpr{&Literal{Text: "a"}}, From: from}
+	}
+	var tests = []struct {
+		name     string
+		stmt     SqlStmt
+		expected []NamedObject
+	}{
+		{
+			name:     "select",
+			stmt:     &SelectStmt{Columns: []SqlExpr{&Literal{Text: "a"}}, From: QualifiedTable("s", "t"), Joins: []JoinClause{{Kind: "cross", Table: Table("u")}}},
+			expected: []NamedObject{{Schema: "s", Object: "t"}, {Object: "u"}},
+		},
+		{
+			name: "common table expressions",
+			stmt: &WithStmt{
+				CTEs: []CommonTableExpr{
+					{Name: "c", Query: query(QualifiedTable("s", "t"))},
+					{Name: "d", Query: query(Table("c"))},
+				},
+				Select: query(Table("d")),
+			},
+			expected: []NamedObject{{Schema: "s", Object: "t"}},
+		},
+		{
+			name: "qualified table named as the expression",
+			stmt: &WithStmt{
+				CTEs:   []CommonTableExpr{{Name: "c", Query: query(QualifiedTable("s", "c"))}},
+				Select: query(Table("c")),
+			},
+			expected: []NamedObject{{Schema: "s", Object: "c"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := CollectTables(test.stmt); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("CollectTables() = %v, expected %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestCollectParameters(t *testing.T) {
+	var (
+		column = func(name string) SqlExpr { return &ColumnRef{Column: &Literal{Text: name}} }
+		query  = func(params ...*ParameterExpr) SqlStmt {
+			var where SqlExpr = &True{}
+			for i, param := range params {
+				where = &BinaryExpr{Left: where, Right: &BinaryExpr{Left: column(fmt.Sprintf("c%d", i)), Right: param, Op: token.EQL}, Op: token.LAND}
+			}
+			return &SelectStmt{Columns: []SqlExpr{column("a")}, From: QualifiedTable("s", "t"), Where: where}
+		}
+	)
+	var tests = []struct {
+		name     string
+		stmt     SqlStmt
+		expected []ParameterExpr
+		err      string
+	}{
+		{
+			name:     "positional",
+			stmt:     query(&ParameterExpr{Position: 2}, &ParameterExpr{Position: 1}, &ParameterExpr{Position: 2}),
+			expected: []ParameterExpr{{Position: 2}, {Position: 1}},
+		},
+		{
+			name:     "named in order of first appearance",
+			stmt:     query(&ParameterExpr{Name: "b"}, &ParameterExpr{Name: "a"}, &ParameterExpr{Name: "b"}),
+			expected: []ParameterExpr{{Name: "b"}, {Name: "a"}},
+		},
+		{
+			name:     "no parameters",
+			stmt:     query(),
+			expected: []ParameterExpr{},
+		},
+		{
+			name: "gap",
+			stmt: query(&ParameterExpr{Position: 1}, &ParameterExpr{Position: 3}),
+			err:  "parameter $2 is missing",
+		},
+		{
+			name: "mixed",
+			stmt: query(&ParameterExpr{Position: 1}, &ParameterExpr{Name: "a"}),
+			err:  "positional and named parameters cannot be mixed",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var actual, err = CollectParameters(test.stmt)
+			if err != nil || test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("CollectParameters() error = %v, expected %q", err, test.err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("CollectParameters() = %v, expected %v", actual, test.expected)
+			}
+		})
+	}
+}
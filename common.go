@@ -1,9 +1,20 @@
 package sql_ast
 
+import (
+	"github.com/iv-menshenin/dragonfly/utils"
+	"strconv"
+	"strings"
+)
+
 type (
+	// TableDesc is the item of FROM clause, it is either the Table or the Query (subquery or function call)
 	TableDesc struct {
 		Table SqlIdent
+		Query SqlExpr
 		Alias string
+		// Lateral allows the Query to refer to the columns of preceding FROM items
+		Lateral bool
+		Sample  *TablesampleClause
 	}
 	NamedObject struct {
 		Schema string
@@ -22,6 +33,8 @@ type (
 		statement() int
 		dependedOn() Dependencies
 		solved() Dependencies
+		// unsolved is the objects removed by the statement
+		unsolved() Dependencies
 	}
 	SqlIdent interface {
 		GetName() string
@@ -41,6 +54,10 @@ const (
 	TargetDomain
 	TargetType
 	TargetConstraint
+	TargetCollation
+	TargetFunction
+	TargetTablespace
+	TargetIndex
 
 	RuleNoAction OnDeleteUpdateRule = iota
 	RuleCascade
@@ -59,6 +76,27 @@ func concatDependencies(a, b Dependencies) Dependencies {
 	return append(a, b...)
 }
 
+// MergeDependencies returns the new list of the dependencies of both lists, duplicates are omitted
+func MergeDependencies(a, b Dependencies) Dependencies {
+	var result = make(Dependencies, 0, len(a)+len(b))
+	for _, deps := range []Dependencies{a, b} {
+		for _, dep := range deps {
+			result = appendUnique(result, dep)
+		}
+	}
+	return result
+}
+
+// DependencyFromObject refers to the object of the schema
+func DependencyFromObject(schema, object string) Dependencies {
+	return dependedOn2(schema, object)
+}
+
+// DependencyFromField refers to the field of the object, e.g. the column of the table
+func DependencyFromField(schema, object, field string) Dependencies {
+	return dependedOn3(schema, object, field)
+}
+
 func dependedOn2(s, n string) Dependencies {
 	return Dependencies{
 		NamedObject{
@@ -78,6 +116,90 @@ func dependedOn3(s, n, f string) Dependencies {
 	}
 }
 
+// Table makes the FROM item of the table, the name can be qualified with the schema: `schema.table`
+func Table(name string) TableDesc {
+	return TableDesc{Table: &Literal{Text: name}}
+}
+
+// QualifiedTable makes the FROM item of the table of the schema
+func QualifiedTable(schema, name string) TableDesc {
+	return TableDesc{Table: &Selector{Container: schema, Name: name}}
+}
+
+// WithAlias returns a copy of the FROM item with the alias
+func (c TableDesc) WithAlias(alias string) TableDesc {
+	c.Alias = alias
+	return c
+}
+
+func (c *TableDesc) String() string {
+	var lateral string
+	if c.Lateral {
+		lateral = "lateral"
+	}
+	if c.Query != nil {
+		if c.Table != nil {
+			panic("TableDesc allows just Table or Query not both")
+		}
+		return utils.NonEmptyStringsConcatSpaceSeparated(lateral, c.Query, c.Alias)
+	}
+	var sample string
+	if c.Sample != nil {
+		sample = c.Sample.String()
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated(lateral, fullName(c.Table), c.Alias, sample)
+}
+
+// dependedOn of the lateral Query contains the columns of preceding FROM items referred by their aliases
+func (c *TableDesc) dependedOn() Dependencies {
+	if c.Query != nil {
+		return c.Query.dependedOn()
+	}
+	if c.Table != nil && c.Sample != nil {
+		return concatDependencies(objectDependencies(c.Table), c.Sample.dependedOn())
+	}
+	if c.Table != nil {
+		return objectDependencies(c.Table)
+	}
+	return nil
+}
+
+// fullName returns the name of the object qualified with the schema if it is known, as it has to be rendered
+func fullName(ident SqlIdent) string {
+	if selector, ok := ident.(*Selector); ok {
+		return selector.FullName()
+	}
+	return ident.GetName()
+}
+
+// objectDependencies resolves the schema of the object if it is possible, otherwise schema stays empty
+func objectDependencies(ident SqlIdent) Dependencies {
+	if name, ok := ident.(*Selector); ok {
+		return dependedOn2(name.Container, name.Name)
+	}
+	if n := strings.Split(ident.GetName(), "."); len(n) > 1 {
+		return dependedOn2(n[0], n[1])
+	}
+	return dependedOn2("", ident.GetName())
+}
+
+// tablespaceDependencies refers to the tablespace, they are not in any schema. Built-in ones are skipped
+func tablespaceDependencies(tablespace SqlIdent) Dependencies {
+	if tablespace == nil || utils.ArrayContainsCI([]string{"pg_default", "pg_global"}, tablespace.GetName()) {
+		return nil
+	}
+	return Dependencies{{Object: tablespace.GetName()}}
+}
+
+// dollarQuote quotes the body with the tag that does not appear in it
+func dollarQuote(body string) string {
+	var tag = "$$"
+	for i := 0; strings.Contains(body, tag); i++ {
+		tag = "$body" + strconv.Itoa(i) + "$"
+	}
+	return tag + body + tag
+}
+
 func (c OnDeleteUpdateRule) String() string {
 	switch c {
 	case RuleCascade:
@@ -101,6 +223,10 @@ var (
 		TargetDomain:     "domain",
 		TargetType:       "type",
 		TargetConstraint: "constraint",
+		TargetCollation:  "collation",
+		TargetFunction:   "function",
+		TargetTablespace: "tablespace",
+		TargetIndex:      "index",
 	}
 )
 
@@ -143,3 +269,7 @@ func ExploreDependencies(stmt SqlStmt) Dependencies {
 func ExploreResolved(stmt SqlStmt) Dependencies {
 	return stmt.solved()
 }
+
+func ExploreUnsolved(stmt SqlStmt) Dependencies {
+	return stmt.unsolved()
+}
@@ -3,6 +3,8 @@ package sql_ast
 import (
 	"fmt"
 	"github.com/iv-menshenin/dragonfly/utils"
+	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -48,6 +50,12 @@ type (
 	TableBodyDescriber struct {
 		Fields      []*SqlField
 		Constraints []ConstraintExpr
+		Inherits    []SqlIdent
+		PartitionBy *PartitionClause
+		PartitionOf *PartitionOfClause
+		Tablespace  SqlIdent
+		// Types is used to refer to the unqualified user-defined types of the fields, they are not dependencies if nil
+		Types *TypeRegistry
 	}
 )
 
@@ -57,9 +65,68 @@ func (c *TableBodyDescriber) String() string {
 		columns = append(columns, fmt.Sprintf("\n\t%s", fld))
 	}
 	for _, cts := range c.Constraints {
+		if c.isInlineConstraint(cts) {
+			continue
+		}
 		columns = append(columns, fmt.Sprintf("\n\t%s", cts.String()))
 	}
-	return "(" + strings.Join(columns, ",") + "\n)"
+	var body = "(" + strings.Join(columns, ",") + "\n)"
+	if c.PartitionOf != nil {
+		if len(columns) == 0 {
+			body = c.PartitionOf.String()
+		} else {
+			body = utils.NonEmptyStringsConcatSpaceSeparated("partition of", fullName(c.PartitionOf.Parent), body, c.PartitionOf.boundString())
+		}
+	}
+	if len(c.Inherits) > 0 {
+		var parents = make([]string, 0, len(c.Inherits))
+		for _, parent := range c.Inherits {
+			parents = append(parents, fullName(parent))
+		}
+		body += " inherits (" + strings.Join(parents, ", ") + ")"
+	}
+	if c.PartitionBy != nil {
+		body += " " + c.PartitionBy.String()
+	}
+	if c.Tablespace != nil {
+		body += " tablespace " + c.Tablespace.GetName()
+	}
+	return body
+}
+
+// isInlineConstraint checks if the unnamed single column unique or primary key constraint
+// is already declared by the column itself
+func (c *TableBodyDescriber) isInlineConstraint(constraint ConstraintExpr) bool {
+	if unnamed, ok := constraint.(*UnnamedConstraintExpr); ok {
+		constraint, ok = unnamed.Constraint.(ConstraintExpr)
+		if !ok {
+			return false
+		}
+	}
+	withColumns, ok := constraint.(*ConstraintWithColumns)
+	if !ok || len(withColumns.Columns) != 1 {
+		return false
+	}
+	var kind = unwrapConstraint(withColumns.Constraint)
+	switch kind.(type) {
+	case *ConstraintUniqueExpr, *ConstraintPrimaryKeyExpr:
+	default:
+		return false
+	}
+	for _, fld := range c.Fields {
+		if fld.Name.GetName() != withColumns.Columns[0] {
+			continue
+		}
+		for _, inline := range fld.Constraints {
+			if _, named := inline.(*NamedConstraintExpr); named {
+				continue
+			}
+			if reflect.TypeOf(unwrapConstraint(inline)) == reflect.TypeOf(kind) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
 func (c *TableBodyDescriber) expression() int { return 0 }
@@ -71,8 +138,58 @@ func (c *TableBodyDescriber) dependedOn() Dependencies {
 	}
 	for _, field := range c.Fields {
 		result = concatDependencies(result, field.dependedOn())
+		if c.Types != nil {
+			result = concatDependencies(result, c.Types.typeDependencies(field.Describer))
+		}
 	}
-	return result
+	for _, parent := range c.Inherits {
+		result = concatDependencies(result, objectDependencies(parent))
+	}
+	if c.PartitionBy != nil {
+		result = concatDependencies(result, c.PartitionBy.dependedOn())
+	}
+	if c.PartitionOf != nil {
+		result = concatDependencies(result, c.PartitionOf.dependedOn())
+	}
+	return concatDependencies(result, tablespaceDependencies(c.Tablespace))
+}
+
+func (c *TableBodyDescriber) AddField(field *SqlField) *TableBodyDescriber {
+	c.Fields = append(c.Fields, field)
+	return c
+}
+
+// RemoveField removes the field by name, reports false if there is no such field
+func (c *TableBodyDescriber) RemoveField(name string) bool {
+	for i, field := range c.Fields {
+		if field.Name.GetName() == name {
+			c.Fields = append(c.Fields[:i], c.Fields[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ReorderFields arranges the fields in order of names, each field must be listed exactly once
+func (c *TableBodyDescriber) ReorderFields(names []string) error {
+	if len(names) != len(c.Fields) {
+		return fmt.Errorf("expected %d field names, got %d", len(c.Fields), len(names))
+	}
+	var byName = make(map[string]*SqlField, len(c.Fields))
+	for _, field := range c.Fields {
+		byName[field.Name.GetName()] = field
+	}
+	var fields = make([]*SqlField, 0, len(names))
+	for _, name := range names {
+		field, ok := byName[name]
+		if !ok {
+			return fmt.Errorf("field `%s` is not found or listed twice", name)
+		}
+		delete(byName, name)
+		fields = append(fields, field)
+	}
+	c.Fields = fields
+	return nil
 }
 
 type (
@@ -80,6 +197,8 @@ type (
 		Name        SqlIdent
 		Describer   *DataTypeExpr
 		Constraints []ConstraintExpr
+		// Storage is one of plain, external, extended, main. It is not a part of the column definition, see AlterStorage
+		Storage string
 	}
 )
 
@@ -103,18 +222,38 @@ func (c *SqlField) dependedOn() Dependencies {
 	return concatDependencies(result, c.Describer.dependedOn())
 }
 
+// AlterStorage returns `alter column name set storage x` for the AlterStmt of the table, nil if Storage is not set
+func (c *SqlField) AlterStorage() *AlterExpr {
+	if c.Storage == "" {
+		return nil
+	}
+	return &AlterExpr{Target: TargetColumn, Name: c.Name, Alter: &StorageExpr{Storage: c.Storage}}
+}
+
 type (
 	DataTypeExpr struct {
-		DataType  string
-		IsArray   bool
-		Length    *int
+		DataType string
+		IsArray  bool
+		// ArrayDimensions is the number of dimensions of the array type, IsArray is the same as one dimension
+		ArrayDimensions int
+		// Length is the length of character types, the precision of numeric and time types: varchar(255), timestamp(6)
+		Length *int
+		// Precision is the scale of the numeric type, it is used along with Length only: numeric(10, 2)
 		Precision *int
 		Collation *string
 	} // NotNull and Default - this is not about data type, this is about Constraints
 )
 
+var timeZoneSuffixes = []string{" with time zone", " without time zone"}
+
 func (c *DataTypeExpr) String() string {
-	var dataType = c.DataType
+	var dataType, suffix = c.DataType, ""
+	for _, s := range timeZoneSuffixes {
+		// the precision of time types goes before the time zone: timestamp(6) with time zone
+		if strings.HasSuffix(strings.ToLower(dataType), s) {
+			dataType, suffix = dataType[:len(dataType)-len(s)], dataType[len(dataType)-len(s):]
+		}
+	}
 	if c.Length != nil {
 		if c.Precision != nil {
 			dataType += fmt.Sprintf("(%d, %d)", *c.Length, *c.Precision)
@@ -122,8 +261,12 @@ func (c *DataTypeExpr) String() string {
 			dataType += fmt.Sprintf("(%d)", *c.Length)
 		}
 	}
-	if c.IsArray {
-		dataType += "[]"
+	dataType += suffix
+	if dimensions := c.ArrayDimensions; dimensions > 0 || c.IsArray {
+		if dimensions == 0 {
+			dimensions = 1
+		}
+		dataType += strings.Repeat("[]", dimensions)
 	}
 	if c.Collation == nil {
 		return dataType
@@ -134,11 +277,17 @@ func (c *DataTypeExpr) String() string {
 
 func (c *DataTypeExpr) expression() int { return 0 }
 
-func (c *DataTypeExpr) dependedOn() Dependencies {
+func (c *DataTypeExpr) dependedOn() (result Dependencies) {
 	if sepType := strings.Split(c.DataType, "."); len(sepType) > 1 {
-		return dependedOn2(sepType[0], sepType[1])
+		result = dependedOn2(sepType[0], sepType[1])
 	}
-	return nil
+	// built-in collations are in pg_catalog, the unqualified ones are considered built-in as well
+	if c.Collation != nil {
+		if sepCollation := strings.Split(*c.Collation, "."); len(sepCollation) > 1 && sepCollation[0] != "pg_catalog" {
+			result = concatDependencies(result, dependedOn2(sepCollation[0], sepCollation[1]))
+		}
+	}
+	return result
 }
 
 type (
@@ -181,3 +330,313 @@ func (c *EnumDescription) expression() int { return 0 }
 func (c *EnumDescription) dependedOn() Dependencies {
 	return nil
 }
+
+type (
+	SequenceOptions struct {
+		Start     *int
+		Increment *int
+		MinValue  *int
+		MaxValue  *int
+		Cache     *int
+		Cycle     bool
+	}
+)
+
+func (c *SequenceOptions) String() string {
+	var options = make([]string, 0, 6)
+	if c.Start != nil {
+		options = append(options, fmt.Sprintf("start with %d", *c.Start))
+	}
+	if c.Increment != nil {
+		options = append(options, fmt.Sprintf("increment by %d", *c.Increment))
+	}
+	if c.MinValue != nil {
+		options = append(options, fmt.Sprintf("minvalue %d", *c.MinValue))
+	}
+	if c.MaxValue != nil {
+		options = append(options, fmt.Sprintf("maxvalue %d", *c.MaxValue))
+	}
+	if c.Cache != nil {
+		options = append(options, fmt.Sprintf("cache %d", *c.Cache))
+	}
+	if c.Cycle {
+		options = append(options, "cycle")
+	}
+	return strings.Join(options, " ")
+}
+
+type (
+	// PartitionClause is `partition by range|list|hash (keys)`
+	PartitionClause struct {
+		Strategy string
+		Keys     []SqlExpr
+	}
+	// PartitionOfClause is `partition of parent for values ...`, the partition is default if Bound is nil
+	PartitionOfClause struct {
+		Parent SqlIdent
+		Bound  SqlExpr
+	}
+	PartitionRangeBound struct {
+		From []SqlExpr
+		To   []SqlExpr
+	}
+	PartitionListBound struct {
+		In []SqlExpr
+	}
+	PartitionHashBound struct {
+		Modulus   int
+		Remainder int
+	}
+)
+
+func joinExpressions(exprs []SqlExpr) string {
+	var s = make([]string, 0, len(exprs))
+	for _, e := range exprs {
+		s = append(s, e.String())
+	}
+	return strings.Join(s, ", ")
+}
+
+func expressionsDependencies(exprs []SqlExpr) Dependencies {
+	var result Dependencies
+	for _, e := range exprs {
+		result = concatDependencies(result, e.dependedOn())
+	}
+	return result
+}
+
+func (c *PartitionClause) String() string {
+	return fmt.Sprintf("partition by %s (%s)", strings.ToLower(c.Strategy), joinExpressions(c.Keys))
+}
+
+func (c *PartitionClause) expression() int { return 0 }
+
+func (c *PartitionClause) dependedOn() Dependencies {
+	return expressionsDependencies(c.Keys)
+}
+
+func (c *PartitionOfClause) String() string {
+	return utils.NonEmptyStringsConcatSpaceSeparated("partition of", fullName(c.Parent), c.boundString())
+}
+
+func (c *PartitionOfClause) boundString() string {
+	if c.Bound == nil {
+		return "default"
+	}
+	return c.Bound.String()
+}
+
+func (c *PartitionOfClause) expression() int { return 0 }
+
+func (c *PartitionOfClause) dependedOn() Dependencies {
+	var result = objectDependencies(c.Parent)
+	if c.Bound != nil {
+		result = concatDependencies(result, c.Bound.dependedOn())
+	}
+	return result
+}
+
+func (c *PartitionRangeBound) String() string {
+	return fmt.Sprintf("for values from (%s) to (%s)", joinExpressions(c.From), joinExpressions(c.To))
+}
+
+func (c *PartitionRangeBound) expression() int { return 0 }
+
+func (c *PartitionRangeBound) dependedOn() Dependencies {
+	return concatDependencies(expressionsDependencies(c.From), expressionsDependencies(c.To))
+}
+
+func (c *PartitionListBound) String() string {
+	return fmt.Sprintf("for values in (%s)", joinExpressions(c.In))
+}
+
+func (c *PartitionListBound) expression() int { return 0 }
+
+func (c *PartitionListBound) dependedOn() Dependencies {
+	return expressionsDependencies(c.In)
+}
+
+func (c *PartitionHashBound) String() string {
+	return fmt.Sprintf("for values with (modulus %d, remainder %d)", c.Modulus, c.Remainder)
+}
+
+func (c *PartitionHashBound) expression() int { return 0 }
+
+func (c *PartitionHashBound) dependedOn() Dependencies {
+	return nil
+}
+
+type (
+	// DomainDescription is the body of `create domain`,
+	// default, not null and check are declared with Constraints like for the table columns
+	DomainDescription struct {
+		BaseType    *DataTypeExpr
+		Constraints []ConstraintExpr
+	}
+)
+
+func (c *DomainDescription) String() string {
+	var constraintsClause = make([]string, 0, len(c.Constraints))
+	for _, constraint := range c.Constraints {
+		constraintsClause = append(constraintsClause, constraint.String())
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated("as", c.BaseType, strings.Join(constraintsClause, " "))
+}
+
+func (c *DomainDescription) expression() int { return 0 }
+
+func (c *DomainDescription) dependedOn() Dependencies {
+	var result = c.BaseType.dependedOn()
+	for _, constraint := range c.Constraints {
+		result = concatDependencies(result, constraint.dependedOn())
+	}
+	return result
+}
+
+type (
+	// CollationDescription is the body of `create collation`, it is either a copy of the existing collation or the options
+	CollationDescription struct {
+		From          SqlIdent
+		Locale        *string
+		LcCollate     *string
+		LcCtype       *string
+		Provider      *string
+		Deterministic *bool
+	}
+)
+
+func (c *CollationDescription) String() string {
+	if c.From != nil {
+		return "from " + fullName(c.From)
+	}
+	var options = make([]string, 0, 5)
+	for _, option := range []struct {
+		name  string
+		value *string
+	}{
+		{"locale", c.Locale},
+		{"lc_collate", c.LcCollate},
+		{"lc_ctype", c.LcCtype},
+	} {
+		if option.value != nil {
+			options = append(options, option.name+" = "+(&String{X: *option.value}).String())
+		}
+	}
+	if c.Provider != nil {
+		options = append(options, "provider = "+*c.Provider)
+	}
+	if c.Deterministic != nil {
+		options = append(options, "deterministic = "+strconv.FormatBool(*c.Deterministic))
+	}
+	return "(" + strings.Join(options, ", ") + ")"
+}
+
+func (c *CollationDescription) expression() int { return 0 }
+
+func (c *CollationDescription) dependedOn() Dependencies {
+	if c.From != nil {
+		return objectDependencies(c.From)
+	}
+	return nil
+}
+
+type (
+	// FunctionParam is `[mode] [name] type [default expr]`, mode is one of in, out, inout, variadic
+	FunctionParam struct {
+		Name    string
+		Mode    string
+		Type    *DataTypeExpr
+		Default SqlExpr
+	}
+	// FunctionDescription is the body of `create function`
+	FunctionDescription struct {
+		Params   []*FunctionParam
+		Returns  *DataTypeExpr
+		Language string
+		Body     string
+	}
+)
+
+func (c *FunctionParam) String() string {
+	var defaultClause string
+	if c.Default != nil {
+		defaultClause = "default " + c.Default.String()
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated(strings.ToLower(c.Mode), c.Name, c.Type, defaultClause)
+}
+
+func (c *FunctionParam) expression() int { return 0 }
+
+func (c *FunctionParam) dependedOn() Dependencies {
+	var result = c.Type.dependedOn()
+	if c.Default != nil {
+		result = concatDependencies(result, c.Default.dependedOn())
+	}
+	return result
+}
+
+func (c *FunctionDescription) String() string {
+	var (
+		params        = make([]string, 0, len(c.Params))
+		returnsClause string
+	)
+	for _, param := range c.Params {
+		params = append(params, param.String())
+	}
+	if c.Returns != nil {
+		returnsClause = "returns " + c.Returns.String()
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated(
+		"("+strings.Join(params, ", ")+")", returnsClause, "language", c.Language, "as", dollarQuote(c.Body),
+	)
+}
+
+func (c *FunctionDescription) expression() int { return 0 }
+
+func (c *FunctionDescription) dependedOn() Dependencies {
+	var result Dependencies
+	for _, param := range c.Params {
+		result = concatDependencies(result, param.dependedOn())
+	}
+	if c.Returns != nil {
+		result = concatDependencies(result, c.Returns.dependedOn())
+	}
+	return result
+}
+
+// CollectOutParams returns out and inout parameters, they describe the record returned by the function
+func CollectOutParams(fn *FunctionDescription) []*FunctionParam {
+	var result = make([]*FunctionParam, 0)
+	for _, param := range fn.Params {
+		if mode := strings.ToLower(param.Mode); mode == "out" || mode == "inout" {
+			result = append(result, param)
+		}
+	}
+	return result
+}
+
+type (
+	// TableAsExpr is the body of `create table name as query [with [no] data]`
+	TableAsExpr struct {
+		Query    SqlStmt
+		WithData *bool
+	}
+)
+
+func (c *TableAsExpr) String() string {
+	var withData string
+	if c.WithData != nil {
+		if *c.WithData {
+			withData = "with data"
+		} else {
+			withData = "with no data"
+		}
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated("as", c.Query, withData)
+}
+
+func (c *TableAsExpr) expression() int { return 0 }
+
+func (c *TableAsExpr) dependedOn() Dependencies {
+	return c.Query.dependedOn()
+}
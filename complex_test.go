@@ -0,0 +1,188 @@
+package sql_ast
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSqlField_default(t *testing.T) {
+	var nextval = func(arg SqlExpr) SqlExpr {
+		return &FncCall{Name: &Literal{Text: "nextval"}, Args: []SqlExpr{arg}}
+	}
+	var tests = []struct {
+		name       string
+		value      SqlExpr
+		expected   string
+		dependedOn Dependencies
+	}{
+		{
+			name:     "literal",
+			value:    &Integer{X: 0},
+			expected: "c integer default 0",
+		},
+		{
+			name:     "function",
+			value:    &FncCall{Name: &Literal{Text: "now"}},
+			expected: "c integer default now()",
+		},
+		{
+			name:       "sequence",
+			value:      nextval(&String{X: "s.seq"}),
+			expected:   "c integer default nextval('s.seq')",
+			dependedOn: Dependencies{{Schema: "s", Object: "seq"}},
+		},
+		{
+			name:       "sequence regclass",
+			value:      nextval(&TypeCastExpr{Operand: &String{X: "s.seq"}, TargetType: &DataTypeExpr{DataType: "regclass"}}),
+			expected:   "c integer default nextval('s.seq'::regclass)",
+			dependedOn: Dependencies{{Schema: "s", Object: "seq"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var field = &SqlField{
+				Name:        &Literal{Text: "c"},
+				Describer:   &DataTypeExpr{DataType: "integer"},
+				Constraints: []ConstraintExpr{&UnnamedConstraintExpr{Constraint: &ConstraintDefaultExpr{Expression: test.value}}},
+			}
+			if actual := field.String(); actual != test.expected {
+				t.Errorf("String() = %q, expected %q", actual, test.expected)
+			}
+			if actual := field.dependedOn(); !reflect.DeepEqual(actual, test.dependedOn) && len(actual)+len(test.dependedOn) > 0 {
+				t.Errorf("dependedOn() = %v, expected %v", actual, test.dependedOn)
+			}
+		})
+	}
+}
+
+func TestTableBodyDescriber_inlineConstraints(t *testing.T) {
+	var (
+		unnamed = func(constraint ConstraintInterface) ConstraintExpr {
+			return &UnnamedConstraintExpr{Constraint: constraint}
+		}
+		tableLevel = func(constraint ConstraintInterface, columns ...string) ConstraintExpr {
+			return &ConstraintWithColumns{Columns: columns, Constraint: unnamed(constraint)}
+		}
+		table = &CreateStmt{Target: TargetTable, Name: &Literal{Text: "t"}, Create: &TableBodyDescriber{
+			Fields: []*SqlField{
+				{Name: &Literal{Text: "id"}, Describer: &DataTypeExpr{DataType: "serial"}, Constraints: []ConstraintExpr{
+					unnamed(&ConstraintPrimaryKeyExpr{}),
+					unnamed(&ConstraintNullableExpr{Nullable: NullableNotNull}),
+				}},
+				{Name: &Literal{Text: "code"}, Describer: &DataTypeExpr{DataType: "text"}, Constraints: []ConstraintExpr{
+					unnamed(&ConstraintNullableExpr{Nullable: NullableNotNull}),
+					unnamed(&ConstraintUniqueExpr{}),
+				}},
+				{Name: &Literal{Text: "name"}, Describer: &DataTypeExpr{DataType: "text"}, Constraints: []ConstraintExpr{
+					&NamedConstraintExpr{Name: &Literal{Text: "name_uq"}, Constraint: &ConstraintUniqueExpr{}},
+				}},
+				{Name: &Literal{Text: "note"}, Describer: &DataTypeExpr{DataType: "text"}, Constraints: []ConstraintExpr{
+					unnamed(&ConstraintNullableExpr{Nullable: NullableNull}),
+				}},
+			},
+			Constraints: []ConstraintExpr{
+				tableLevel(&ConstraintPrimaryKeyExpr{}, "id"),
+				&UnnamedConstraintExpr{Constraint: tableLevel(&ConstraintUniqueExpr{}, "code")},
+				tableLevel(&ConstraintUniqueExpr{}, "name"),
+				tableLevel(&ConstraintUniqueExpr{}, "code", "name"),
+				&NamedConstraintExpr{Name: &Literal{Text: "code_uq"}, Constraint: tableLevel(&ConstraintUniqueExpr{}, "code")},
+			},
+		}}
+	)
+	const expected = "create table t (\n" +
+		"\tid serial primary key not null,\n" +
+		"\tcode text not null unique,\n" +
+		"\tname text constraint name_uq unique,\n" +
+		"\tnote text null,\n" +
+		"\tunique (name),\n" +
+		"\tunique (code, name),\n" +
+		"\tconstraint code_uq unique (code)\n" +
+		")"
+	if actual := table.String(); actual != expected {
+		t.Errorf("unexpected table:\n%s\nexpected:\n%s", actual, expected)
+	}
+}
+
+func TestDataTypeExpr_collation(t *testing.T) {
+	var (
+		collation = func(name string) *string { return &name }
+		table     = &CreateStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: "t"}, Create: &TableBodyDescriber{
+			Fields: []*SqlField{
+				{Name: &Literal{Text: "id"}, Describer: &DataTypeExpr{DataType: "integer"}},
+				{Name: &Literal{Text: "code"}, Describer: &DataTypeExpr{DataType: "text"}},
+				{Name: &Literal{Text: "title"}, Describer: &DataTypeExpr{DataType: "text", Collation: collation("de_DE")}},
+				{Name: &Literal{Text: "name"}, Describer: &DataTypeExpr{DataType: "varchar", Collation: collation("s.case_insensitive")}},
+				{Name: &Literal{Text: "note"}, Describer: &DataTypeExpr{DataType: "text", Collation: collation("pg_catalog.default")}},
+			},
+		}}
+	)
+	const expected = "create table s.t (\n" +
+		"\tid integer,\n" +
+		"\tcode text,\n" +
+		"\ttitle text collate de_DE,\n" +
+		"\tname varchar collate s.case_insensitive,\n" +
+		"\tnote text collate pg_catalog.default\n" +
+		")"
+	if actual := table.String(); actual != expected {
+		t.Errorf("unexpected table:\n%s\nexpected:\n%s", actual, expected)
+	}
+	var dependencies = Dependencies{{Schema: "s", Object: "case_insensitive"}}
+	if actual := table.Create.dependedOn(); !reflect.DeepEqual(actual, dependencies) {
+		t.Errorf("dependedOn() = %v, expected %v", actual, dependencies)
+	}
+}
+
+func TestDataTypeExpr_modifiers(t *testing.T) {
+	var modifier = func(x int) *int { return &x }
+	var tests = []struct {
+		dataType *DataTypeExpr
+		expected string
+	}{
+		{dataType: &DataTypeExpr{DataType: "numeric"}, expected: "numeric"},
+		{dataType: &DataTypeExpr{DataType: "numeric", Length: modifier(10)}, expected: "numeric(10)"},
+		{dataType: &DataTypeExpr{DataType: "numeric", Length: modifier(10), Precision: modifier(2)}, expected: "numeric(10, 2)"},
+		{dataType: &DataTypeExpr{DataType: "numeric", Length: modifier(10), Precision: modifier(0)}, expected: "numeric(10, 0)"},
+		{dataType: &DataTypeExpr{DataType: "numeric", Precision: modifier(2)}, expected: "numeric"},
+		{dataType: &DataTypeExpr{DataType: "varchar", Length: modifier(255)}, expected: "varchar(255)"},
+		{dataType: &DataTypeExpr{DataType: "timestamp", Length: modifier(6)}, expected: "timestamp(6)"},
+		{dataType: &DataTypeExpr{DataType: "timestamp", Length: modifier(0)}, expected: "timestamp(0)"},
+		{dataType: &DataTypeExpr{DataType: "timestamp with time zone", Length: modifier(6)}, expected: "timestamp(6) with time zone"},
+		{dataType: &DataTypeExpr{DataType: "time without time zone", Length: modifier(0)}, expected: "time(0) without time zone"},
+		{dataType: &DataTypeExpr{DataType: "timestamp with time zone"}, expected: "timestamp with time zone"},
+	}
+	for _, test := range tests {
+		if actual := test.dataType.String(); actual != test.expected {
+			t.Errorf("String() = %q, expected %q", actual, test.expected)
+		}
+	}
+}
+
+func TestDataTypeExpr_arrayDimensions(t *testing.T) {
+	var types = newPostgresTypes()
+	var tests = []struct {
+		dataType   *DataTypeExpr
+		expected   string
+		dependedOn Dependencies
+	}{
+		{dataType: &DataTypeExpr{DataType: "text", IsArray: true}, expected: "text[]"},
+		{dataType: &DataTypeExpr{DataType: "text", ArrayDimensions: 1}, expected: "text[]"},
+		{dataType: &DataTypeExpr{DataType: "text", ArrayDimensions: 2}, expected: "text[][]"},
+		{dataType: &DataTypeExpr{DataType: "integer", ArrayDimensions: 3, IsArray: true}, expected: "integer[][][]"},
+		{dataType: &DataTypeExpr{DataType: "varchar", Length: new(int), ArrayDimensions: 2}, expected: "varchar(0)[][]"},
+		{dataType: &DataTypeExpr{DataType: "s.mood", ArrayDimensions: 1}, expected: "s.mood[]", dependedOn: Dependencies{{Schema: "s", Object: "mood"}}},
+		{dataType: &DataTypeExpr{DataType: "s.mood", ArrayDimensions: 2}, expected: "s.mood[][]", dependedOn: Dependencies{{Schema: "s", Object: "mood"}}},
+		{dataType: &DataTypeExpr{DataType: "s.mood", ArrayDimensions: 3}, expected: "s.mood[][][]", dependedOn: Dependencies{{Schema: "s", Object: "mood"}}},
+		{dataType: &DataTypeExpr{DataType: "mood", ArrayDimensions: 3}, expected: "mood[][][]", dependedOn: Dependencies{{Object: "mood"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.expected, func(t *testing.T) {
+			if actual := test.dataType.String(); actual != test.expected {
+				t.Errorf("String() = %q, expected %q", actual, test.expected)
+			}
+			var dependencies = concatDependencies(test.dataType.dependedOn(), types.typeDependencies(test.dataType))
+			if !reflect.DeepEqual(dependencies, test.dependedOn) && len(dependencies)+len(test.dependedOn) > 0 {
+				t.Errorf("dependencies = %v, expected %v", dependencies, test.dependedOn)
+			}
+		})
+	}
+}
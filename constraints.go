@@ -6,6 +6,18 @@ import (
 	"strings"
 )
 
+const (
+	DeferrabilityDefault Deferrability = iota
+	NotDeferrable
+	Deferrable
+)
+
+const (
+	InitiallyDefault ConstraintCheckTime = iota
+	InitiallyImmediate
+	InitiallyDeferred
+)
+
 type (
 	ConstraintExpr interface {
 		ConstraintInterface
@@ -30,6 +42,13 @@ type (
 	ConstraintCommon struct {
 		InColumn bool
 	}
+	// [[not] deferrable] [initially deferred | initially immediate], the zero values are omitted
+	ConstraintDeferrable struct {
+		Deferrable Deferrability
+		Initially  ConstraintCheckTime
+	}
+	Deferrability       int
+	ConstraintCheckTime int
 	// not null
 	ConstraintNullableExpr struct {
 		ConstraintCommon
@@ -40,30 +59,82 @@ type (
 		ConstraintCommon
 		Expression SqlExpr
 		Where      SqlExpr
+		NoInherit  bool
 	}
 	// default
 	ConstraintDefaultExpr struct {
 		ConstraintCommon
 		Expression SqlExpr
 	}
+	// generated always as (expr) stored
+	ConstraintGeneratedExpr struct {
+		ConstraintCommon
+		Expression SqlExpr
+		Stored     bool
+	}
+	// generated always as identity
+	ConstraintIdentityExpr struct {
+		ConstraintCommon
+		Always  bool
+		Options *SequenceOptions
+	}
 	// primary key
 	ConstraintPrimaryKeyExpr struct {
 		ConstraintCommon
+		ConstraintDeferrable
 	}
 	// unique
 	ConstraintUniqueExpr struct {
 		ConstraintCommon
+		ConstraintDeferrable
 	}
 	// foreign key
 	ConstraintForeignKeyExpr struct {
 		ConstraintCommon
-		ToTable  SqlIdent
-		ToColumn string
-		OnDelete OnDeleteUpdateRule
-		OnUpdate OnDeleteUpdateRule
+		ConstraintDeferrable
+		ToTable   SqlIdent
+		ToColumn  string
+		ToColumns []string // composite key, ToColumn is ignored if set
+		OnDelete  OnDeleteUpdateRule
+		OnUpdate  OnDeleteUpdateRule
 	}
 )
 
+// NewPrimaryKeyConstraint makes the table constraint `[constraint name] primary key (columns)`, name can be nil
+func NewPrimaryKeyConstraint(name SqlIdent, columns ...string) ConstraintExpr {
+	return newTableConstraint(name, columns, &ConstraintPrimaryKeyExpr{})
+}
+
+// NewUniqueConstraint makes the table constraint `[constraint name] unique (columns)`, name can be nil
+func NewUniqueConstraint(name SqlIdent, columns ...string) ConstraintExpr {
+	return newTableConstraint(name, columns, &ConstraintUniqueExpr{})
+}
+
+func newTableConstraint(name SqlIdent, columns []string, constraint ConstraintInterface) ConstraintExpr {
+	var withColumns = &ConstraintWithColumns{
+		Columns:    columns,
+		Constraint: &UnnamedConstraintExpr{Constraint: constraint},
+	}
+	if name == nil {
+		return withColumns
+	}
+	return &NamedConstraintExpr{Name: name, Constraint: withColumns}
+}
+
+// unwrapConstraint returns the constraint without the name
+func unwrapConstraint(constraint ConstraintInterface) ConstraintInterface {
+	for {
+		switch c := constraint.(type) {
+		case *NamedConstraintExpr:
+			constraint = c.Constraint
+		case *UnnamedConstraintExpr:
+			constraint = c.Constraint
+		default:
+			return constraint
+		}
+	}
+}
+
 func (c *NamedConstraintExpr) ConstraintString() string {
 	return utils.NonEmptyStringsConcatSpaceSeparated("constraint", c.Name.GetName(), c.Constraint.ConstraintString())
 }
@@ -109,7 +180,7 @@ func (c *UnnamedConstraintExpr) dependencies() Dependencies {
 }
 
 func (c *ConstraintWithColumns) ConstraintString() string {
-	return utils.NonEmptyStringsConcatSpaceSeparated(c.Constraint.ConstraintString(), "(", strings.Join(c.Columns, ", "), ")")
+	return utils.NonEmptyStringsConcatSpaceSeparated(c.Constraint.ConstraintString(), "("+strings.Join(c.Columns, ", ")+")")
 }
 
 func (c *ConstraintWithColumns) ConstraintParams() string {
@@ -153,6 +224,9 @@ func (c *ConstraintNullableExpr) dependencies() Dependencies {
 }
 
 func (c *ConstraintCheckExpr) ConstraintString() string {
+	if c.NoInherit {
+		return fmt.Sprintf("check (%s) no inherit", c.Expression.String())
+	}
 	return fmt.Sprintf("check (%s)", c.Expression.String())
 }
 
@@ -164,7 +238,11 @@ func (c *ConstraintCheckExpr) ConstraintParams() string {
 }
 
 func (c *ConstraintCheckExpr) dependencies() Dependencies {
-	return nil
+	var result = c.Expression.dependedOn()
+	if c.Where != nil {
+		result = concatDependencies(result, c.Where.dependedOn())
+	}
+	return result
 }
 
 func (c *ConstraintDefaultExpr) ConstraintString() string {
@@ -176,15 +254,68 @@ func (c *ConstraintDefaultExpr) ConstraintParams() string {
 }
 
 func (c *ConstraintDefaultExpr) dependencies() Dependencies {
+	return c.Expression.dependedOn()
+}
+
+func (c *ConstraintGeneratedExpr) ConstraintString() string {
+	if c.Stored {
+		return fmt.Sprintf("generated always as (%s) stored", c.Expression.String())
+	}
+	return fmt.Sprintf("generated always as (%s)", c.Expression.String())
+}
+
+func (c *ConstraintGeneratedExpr) ConstraintParams() string {
+	return ""
+}
+
+func (c *ConstraintGeneratedExpr) dependencies() Dependencies {
+	return c.Expression.dependedOn()
+}
+
+func (c *ConstraintIdentityExpr) ConstraintString() string {
+	if c.Always {
+		return "generated always as identity"
+	}
+	return "generated by default as identity"
+}
+
+func (c *ConstraintIdentityExpr) ConstraintParams() string {
+	if c.Options == nil {
+		return ""
+	}
+	return "(" + c.Options.String() + ")"
+}
+
+func (c *ConstraintIdentityExpr) dependencies() Dependencies {
 	return nil
 }
 
+func (c *ConstraintDeferrable) deferrableClause() string {
+	var deferrable, initially string
+	switch c.Deferrable {
+	case NotDeferrable:
+		if c.Initially == InitiallyDeferred {
+			panic("not deferrable constraint cannot be initially deferred")
+		}
+		deferrable = "not deferrable"
+	case Deferrable:
+		deferrable = "deferrable"
+	}
+	switch c.Initially {
+	case InitiallyImmediate:
+		initially = "initially immediate"
+	case InitiallyDeferred:
+		initially = "initially deferred"
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated(deferrable, initially)
+}
+
 func (c *ConstraintPrimaryKeyExpr) ConstraintString() string {
 	return "primary key"
 }
 
 func (c *ConstraintPrimaryKeyExpr) ConstraintParams() string {
-	return ""
+	return c.deferrableClause()
 }
 
 func (c *ConstraintPrimaryKeyExpr) dependencies() Dependencies {
@@ -196,7 +327,7 @@ func (c *ConstraintUniqueExpr) ConstraintString() string {
 }
 
 func (c *ConstraintUniqueExpr) ConstraintParams() string {
-	return ""
+	return c.deferrableClause()
 }
 
 func (c *ConstraintUniqueExpr) dependencies() Dependencies {
@@ -218,12 +349,25 @@ func (c *ConstraintForeignKeyExpr) ConstraintParams() string {
 	if int(c.OnDelete) > -1 {
 		updateRules += fmt.Sprintf(" on delete %s", c.OnDelete)
 	}
-	return fmt.Sprintf("references %s (%s)%s", c.ToTable.GetName(), c.ToColumn, updateRules)
+	return utils.NonEmptyStringsConcatSpaceSeparated(
+		fmt.Sprintf("references %s (%s)%s", fullName(c.ToTable), strings.Join(c.referencedColumns(), ", "), updateRules),
+		c.deferrableClause(),
+	)
+}
+
+func (c *ConstraintForeignKeyExpr) referencedColumns() []string {
+	if len(c.ToColumns) > 0 {
+		return c.ToColumns
+	}
+	return []string{c.ToColumn}
 }
 
-func (c *ConstraintForeignKeyExpr) dependencies() Dependencies {
-	if tableName := strings.Split(c.ToTable.GetName(), "."); len(tableName) > 1 {
-		return dependedOn3(tableName[0], tableName[1], c.ToColumn)
+func (c *ConstraintForeignKeyExpr) dependencies() (result Dependencies) {
+	if table := objectDependencies(c.ToTable)[0]; table.Schema != "" {
+		for _, column := range c.referencedColumns() {
+			result = concatDependencies(result, dependedOn3(table.Schema, table.Object, column))
+		}
+		return result
 	}
-	panic("unknown schema for table `" + c.ToTable.GetName() + "`")
+	panic("unknown schema for table `" + fullName(c.ToTable) + "`")
 }
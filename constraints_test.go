@@ -0,0 +1,49 @@
+package sql_ast
+
+import (
+	"testing"
+)
+
+func TestConstraintDeferrable(t *testing.T) {
+	var tests = []struct {
+		deferrable ConstraintDeferrable
+		expected   string
+	}{
+		{expected: "unique (a)"},
+		{deferrable: ConstraintDeferrable{Deferrable: Deferrable}, expected: "unique (a) deferrable"},
+		{deferrable: ConstraintDeferrable{Deferrable: NotDeferrable}, expected: "unique (a) not deferrable"},
+		{deferrable: ConstraintDeferrable{Deferrable: NotDeferrable, Initially: InitiallyImmediate}, expected: "unique (a) not deferrable initially immediate"},
+		{deferrable: ConstraintDeferrable{Deferrable: Deferrable, Initially: InitiallyImmediate}, expected: "unique (a) deferrable initially immediate"},
+		{deferrable: ConstraintDeferrable{Deferrable: Deferrable, Initially: InitiallyDeferred}, expected: "unique (a) deferrable initially deferred"},
+		{deferrable: ConstraintDeferrable{Initially: InitiallyDeferred}, expected: "unique (a) initially deferred"},
+	}
+	for _, test := range tests {
+		var constraint = &ConstraintWithColumns{
+			Columns:    []string{"a"},
+			Constraint: &UnnamedConstraintExpr{Constraint: &ConstraintUniqueExpr{ConstraintDeferrable: test.deferrable}},
+		}
+		if actual := constraint.String(); actual != test.expected {
+			t.Errorf("String() = %q, expected %q", actual, test.expected)
+		}
+	}
+	var foreignKey = &NamedConstraintExpr{Name: &Literal{Text: "fk"}, Constraint: &ConstraintForeignKeyExpr{
+		ToTable:              &Literal{Text: "t"},
+		ToColumn:             "id",
+		OnDelete:             -1,
+		OnUpdate:             -1,
+		ConstraintDeferrable: ConstraintDeferrable{Deferrable: NotDeferrable},
+	}}
+	if actual := foreignKey.String(); actual != "constraint fk foreign key references t (id) not deferrable" {
+		t.Errorf("unexpected foreign key: %q", actual)
+	}
+}
+
+func TestConstraintDeferrable_invalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("not deferrable initially deferred constraint has to panic")
+		}
+	}()
+	var deferrable = ConstraintDeferrable{Deferrable: NotDeferrable, Initially: InitiallyDeferred}
+	deferrable.deferrableClause()
+}
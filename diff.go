@@ -0,0 +1,300 @@
+package sql_ast
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type (
+	StatementDiffKind int
+	StatementDiff     struct {
+		Kind StatementDiffKind
+		Old  SqlStmt
+		New  SqlStmt
+	}
+)
+
+const (
+	DiffAdded StatementDiffKind = iota
+	DiffRemoved
+	DiffModified
+)
+
+func (c StatementDiffKind) String() string {
+	switch c {
+	case DiffAdded:
+		return "added"
+	case DiffRemoved:
+		return "removed"
+	default:
+		return "modified"
+	}
+}
+
+func (c *StatementDiff) String() string {
+	switch c.Kind {
+	case DiffAdded:
+		return "+ " + c.New.String()
+	case DiffRemoved:
+		return "- " + c.Old.String()
+	default:
+		return "- " + c.Old.String() + "\n+ " + c.New.String()
+	}
+}
+
+// DiffStatements matches the statements by the objects they resolve and compares the pairs structurally.
+// Statements that resolve nothing are matched by their text, therefore they can be added or removed only
+func DiffStatements(oldStmts, newStmts []SqlStmt) []StatementDiff {
+	var (
+		result  = make([]StatementDiff, 0)
+		matched = make(map[string][]SqlStmt)
+		keys    = make([]string, 0, len(newStmts))
+	)
+	for _, stmt := range newStmts {
+		var key = statementIdentity(stmt)
+		matched[key] = append(matched[key], stmt)
+		keys = append(keys, key)
+	}
+	for _, stmt := range oldStmts {
+		var key = statementIdentity(stmt)
+		if candidates := matched[key]; len(candidates) > 0 {
+			matched[key] = candidates[1:]
+			if !reflect.DeepEqual(stmt, candidates[0]) {
+				result = append(result, StatementDiff{Kind: DiffModified, Old: stmt, New: candidates[0]})
+			}
+			continue
+		}
+		result = append(result, StatementDiff{Kind: DiffRemoved, Old: stmt})
+	}
+	for i, stmt := range newStmts {
+		if candidates := matched[keys[i]]; len(candidates) > 0 && candidates[0] == stmt {
+			matched[keys[i]] = candidates[1:]
+			result = append(result, StatementDiff{Kind: DiffAdded, New: stmt})
+		}
+	}
+	return result
+}
+
+func statementIdentity(stmt SqlStmt) string {
+	if solved := stmt.solved(); len(solved) > 0 {
+		return fmt.Sprintf("%T %s.%s.%s", stmt, solved[0].Schema, solved[0].Object, solved[0].Field)
+	}
+	return fmt.Sprintf("%T %s", stmt, stmt.String())
+}
+
+type (
+	schemaSnapshot struct {
+		tables      map[NamedObject]*CreateStmt
+		tableOrder  []NamedObject
+		indexes     map[string]*CreateIndexStmt
+		indexOrder  []string
+		indexSchema map[string]string
+	}
+)
+
+func newSchemaSnapshot(stmts []SqlStmt) *schemaSnapshot {
+	var snapshot = schemaSnapshot{
+		tables:      make(map[NamedObject]*CreateStmt),
+		indexes:     make(map[string]*CreateIndexStmt),
+		indexSchema: make(map[string]string),
+	}
+	for _, stmt := range stmts {
+		switch n := stmt.(type) {
+		case *CreateStmt:
+			if _, ok := n.Create.(*TableBodyDescriber); ok && n.Target == TargetTable {
+				var s, o = resolveObjectName(n.Target, n.Name)
+				var key = NamedObject{Schema: s, Object: o}
+				snapshot.tables[key] = n
+				snapshot.tableOrder = append(snapshot.tableOrder, key)
+			}
+		case *CreateIndexStmt:
+			// unnamed indexes are identified by the definition
+			var key = n.String()
+			if solved := n.solved(); len(solved) > 0 {
+				key = solved[0].Schema + "." + solved[0].Object
+				snapshot.indexSchema[key] = solved[0].Schema
+			}
+			snapshot.indexes[key] = n
+			snapshot.indexOrder = append(snapshot.indexOrder, key)
+		}
+	}
+	return &snapshot
+}
+
+// DiffSchemas returns the statements migrating the schema defined by oldStmts to the one defined by newStmts.
+// Tables and indexes are compared, the other statements are ignored. Changed indexes and named table constraints
+// are recreated, therefore the old index has to be named if it is changed or removed, see diffColumns
+func DiffSchemas(oldStmts, newStmts []SqlStmt) (StatementList, error) {
+	var (
+		oldSchema  = newSchemaSnapshot(oldStmts)
+		newSchema  = newSchemaSnapshot(newStmts)
+		dropIndex  = make(StatementList, 0)
+		createStmt = make(StatementList, 0)
+		alterStmt  = make(StatementList, 0)
+		indexStmt  = make(StatementList, 0)
+		dropTable  = make(StatementList, 0)
+	)
+	for _, key := range oldSchema.indexOrder {
+		if index, ok := newSchema.indexes[key]; ok && reflect.DeepEqual(index, oldSchema.indexes[key]) {
+			continue
+		}
+		schema, ok := oldSchema.indexSchema[key]
+		if !ok {
+			return nil, fmt.Errorf("unnamed index cannot be dropped: %s", key)
+		}
+		dropIndex = append(dropIndex, &DropStmt{Target: TargetIndex, Name: objectIdent(schema, oldSchema.indexes[key].Name.GetName())})
+	}
+	for _, key := range newSchema.tableOrder {
+		var table, ok = oldSchema.tables[key]
+		if !ok {
+			createStmt = append(createStmt, newSchema.tables[key])
+			continue
+		}
+		alter, err := diffColumns(table, newSchema.tables[key])
+		if err != nil {
+			return nil, err
+		}
+		alterStmt = append(alterStmt, alter...)
+	}
+	for _, key := range newSchema.indexOrder {
+		if index, ok := oldSchema.indexes[key]; !ok || !reflect.DeepEqual(index, newSchema.indexes[key]) {
+			indexStmt = append(indexStmt, newSchema.indexes[key])
+		}
+	}
+	for _, key := range oldSchema.tableOrder {
+		if _, ok := newSchema.tables[key]; !ok {
+			dropTable = append(dropTable, &DropStmt{Target: TargetTable, Name: oldSchema.tables[key].Name})
+		}
+	}
+	var result = make(StatementList, 0, len(dropIndex)+len(createStmt)+len(alterStmt)+len(indexStmt)+len(dropTable))
+	for _, stmts := range []StatementList{dropIndex, createStmt, alterStmt, indexStmt, dropTable} {
+		result = append(result, stmts...)
+	}
+	return result, nil
+}
+
+// diffColumns returns `alter table` statements adding, retyping and dropping the columns, changing their defaults
+// and nullability and recreating the changed named constraints of the table. The other column constraints
+// and the unnamed table constraints cannot be altered, their changes are reported
+func diffColumns(oldTable, newTable *CreateStmt) (StatementList, error) {
+	var (
+		result    = make(StatementList, 0)
+		oldBody   = oldTable.Create.(*TableBodyDescriber)
+		newBody   = newTable.Create.(*TableBodyDescriber)
+		oldFields = make(map[string]*SqlField)
+		newFields = make(map[string]bool)
+		alter     = func(expr SqlExpr) {
+			result = append(result, &AlterStmt{Target: TargetTable, Name: newTable.Name, Alter: expr})
+		}
+	)
+	dropConstraints, addConstraints, err := diffConstraints(oldTable, newTable)
+	if err != nil {
+		return nil, err
+	}
+	for _, expr := range dropConstraints {
+		alter(expr)
+	}
+	for _, field := range oldBody.Fields {
+		oldFields[field.Name.GetName()] = field
+	}
+	for _, field := range newBody.Fields {
+		var name = field.Name.GetName()
+		newFields[name] = true
+		old, ok := oldFields[name]
+		if !ok {
+			alter(&AddColumnExpr{Column: field})
+			continue
+		}
+		if !reflect.DeepEqual(old.Describer, field.Describer) {
+			alter(&AlterColumnTypeExpr{Column: field.Name, NewType: field.Describer})
+		}
+		var (
+			oldDefault, oldNotNull, oldOther = columnConstraints(old)
+			newDefault, newNotNull, newOther = columnConstraints(field)
+		)
+		if !reflect.DeepEqual(oldOther, newOther) {
+			return nil, fmt.Errorf("constraints of column `%s.%s` cannot be altered: %s", fullName(newTable.Name), name, field)
+		}
+		if !reflect.DeepEqual(oldDefault, newDefault) {
+			// the empty Default drops it
+			alter(&AlterExpr{Target: TargetColumn, Name: field.Name, Alter: &Default{Default: newDefault}})
+		}
+		if oldNotNull != newNotNull {
+			alter(&AlterExpr{Target: TargetColumn, Name: field.Name, Alter: &SetDropExpr{SetDrop: SetDrop(newNotNull), Expr: &NotNullClause{}}})
+		}
+	}
+	for _, field := range oldBody.Fields {
+		if !newFields[field.Name.GetName()] {
+			alter(&DropColumnExpr{Column: field.Name})
+		}
+	}
+	for _, expr := range addConstraints {
+		alter(expr)
+	}
+	return result, nil
+}
+
+// columnConstraints splits the constraints of the column into the default value, the nullability
+// and the rest of the constraints
+func columnConstraints(field *SqlField) (value SqlExpr, notNull bool, other []ConstraintExpr) {
+	for _, constraint := range field.Constraints {
+		switch c := unwrapConstraint(constraint).(type) {
+		case *ConstraintDefaultExpr:
+			value = c.Expression
+		case *ConstraintNullableExpr:
+			notNull = c.Nullable == NullableNotNull
+		default:
+			other = append(other, constraint)
+		}
+	}
+	return value, notNull, other
+}
+
+// diffConstraints returns the expressions dropping the changed and removed named constraints of the table
+// and adding the changed and new ones. The unnamed constraints get their names from the server,
+// therefore they cannot be dropped and they are not expected to be changed
+func diffConstraints(oldTable, newTable *CreateStmt) (drop, add []SqlExpr, err error) {
+	var (
+		oldNamed, oldUnnamed = splitNamedConstraints(oldTable.Create.(*TableBodyDescriber).Constraints)
+		newNamed, newUnnamed = splitNamedConstraints(newTable.Create.(*TableBodyDescriber).Constraints)
+	)
+	if !reflect.DeepEqual(oldUnnamed, newUnnamed) {
+		return nil, nil, fmt.Errorf("unnamed constraints of table `%s` cannot be altered", fullName(newTable.Name))
+	}
+	for _, old := range oldNamed {
+		if !containsConstraint(newNamed, old) {
+			drop = append(drop, &DropExpr{Target: TargetConstraint, Name: old.Name})
+		}
+	}
+	for _, constraint := range newNamed {
+		if containsConstraint(oldNamed, constraint) {
+			continue
+		}
+		definition, ok := constraint.Constraint.(SqlExpr)
+		if !ok {
+			return nil, nil, fmt.Errorf("constraint `%s` of table `%s` cannot be added", constraint.Name.GetName(), fullName(newTable.Name))
+		}
+		add = append(add, &AddExpr{Target: TargetConstraint, Name: constraint.Name, Definition: definition})
+	}
+	return drop, add, nil
+}
+
+func splitNamedConstraints(constraints []ConstraintExpr) (named []*NamedConstraintExpr, unnamed []ConstraintExpr) {
+	for _, constraint := range constraints {
+		if n, ok := constraint.(*NamedConstraintExpr); ok {
+			named = append(named, n)
+		} else {
+			unnamed = append(unnamed, constraint)
+		}
+	}
+	return named, unnamed
+}
+
+func containsConstraint(constraints []*NamedConstraintExpr, constraint *NamedConstraintExpr) bool {
+	for _, c := range constraints {
+		if reflect.DeepEqual(c, constraint) {
+			return true
+		}
+	}
+	return false
+}
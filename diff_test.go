@@ -0,0 +1,186 @@
+package sql_ast
+
+import (
+	"testing"
+)
+
+func TestDiffSchemas(t *testing.T) {
+	var (
+		table = func(fields ...string) SqlStmt {
+			var body = &TableBodyDescriber{}
+			for i := 0; i < len(fields); i += 2 {
+				body.AddField(&SqlField{Name: &Literal{Text: fields[i]}, Describer: &DataTypeExpr{DataType: fields[i+1]}})
+			}
+			return &CreateStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: "t"}, Create: body}
+		}
+		index = func(name, column string) SqlStmt {
+			var stmt = &CreateIndexStmt{Table: &Selector{Container: "s", Name: "t"}, Keys: []IndexKey{&ColumnIndexKey{Column: &Literal{Text: column}}}}
+			if name != "" {
+				stmt.Name = &Literal{Text: name}
+			}
+			return stmt
+		}
+	)
+	var tests = []struct {
+		name     string
+		old, new []SqlStmt
+		expected string
+		err      string
+	}{
+		{name: "add column", old: []SqlStmt{table("a", "int")}, new: []SqlStmt{table("a", "int", "b", "text")}, expected: "alter table s.t add column b text;"},
+		{name: "drop column", old: []SqlStmt{table("a", "int", "b", "text")}, new: []SqlStmt{table("a", "int")}, expected: "alter table s.t drop column b;"},
+		{name: "change type", old: []SqlStmt{table("a", "int")}, new: []SqlStmt{table("a", "bigint")}, expected: "alter table s.t alter column a type bigint;"},
+		{name: "create table", new: []SqlStmt{table("a", "int")}, expected: "create table s.t (\n\ta int\n);"},
+		{name: "drop table", old: []SqlStmt{table("a", "int")}, expected: "drop table s.t;"},
+		{
+			name:     "change named index",
+			old:      []SqlStmt{table("a", "int"), index("i", "a")},
+			new:      []SqlStmt{table("a", "int", "b", "int"), index("i", "b")},
+			expected: "drop index s.i;\nalter table s.t add column b int;\ncreate index i on s.t (b);",
+		},
+		{
+			name: "keep unnamed index",
+			old:  []SqlStmt{table("a", "int"), index("", "a")},
+			new:  []SqlStmt{table("a", "int"), index("", "a")},
+		},
+		{
+			name: "change unnamed index",
+			old:  []SqlStmt{table("a", "int"), index("", "a")},
+			new:  []SqlStmt{table("a", "int", "b", "int"), index("", "b")},
+			err:  "unnamed index cannot be dropped: create index on s.t (a)",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var result, err = DiffSchemas(test.old, test.new)
+			if err != nil {
+				if err.Error() != test.err {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if test.err != "" {
+				t.Errorf("expected error: %s", test.err)
+			}
+			if actual := result.String(); actual != test.expected {
+				t.Errorf("DiffSchemas() = %q, expected %q", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestDiffSchemas_constraints(t *testing.T) {
+	var (
+		unnamed = func(constraint ConstraintInterface) ConstraintExpr {
+			return &UnnamedConstraintExpr{Constraint: constraint}
+		}
+		column = func(constraints ...ConstraintExpr) *SqlField {
+			return &SqlField{Name: &Literal{Text: "a"}, Describer: &DataTypeExpr{DataType: "int"}, Constraints: constraints}
+		}
+		table = func(field *SqlField, constraints ...ConstraintExpr) []SqlStmt {
+			var body = (&TableBodyDescriber{Constraints: constraints}).AddField(field)
+			return []SqlStmt{&CreateStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: "t"}, Create: body}}
+		}
+		unique = func(columns ...string) ConstraintExpr {
+			return &ConstraintWithColumns{Columns: columns, Constraint: unnamed(&ConstraintUniqueExpr{})}
+		}
+		named = func(name string, constraint ConstraintExpr) ConstraintExpr {
+			return &NamedConstraintExpr{Name: &Literal{Text: name}, Constraint: constraint}
+		}
+		defaultValue = unnamed(&ConstraintDefaultExpr{Expression: &Integer{X: 0}})
+		notNull      = unnamed(&ConstraintNullableExpr{Nullable: NullableNotNull})
+	)
+	var tests = []struct {
+		name     string
+		old, new []SqlStmt
+		expected string
+		err      string
+	}{
+		{name: "set default", old: table(column()), new: table(column(defaultValue)), expected: "alter table s.t alter column a set default 0;"},
+		{name: "drop default", old: table(column(defaultValue)), new: table(column()), expected: "alter table s.t alter column a drop default;"},
+		{name: "set not null", old: table(column()), new: table(column(notNull)), expected: "alter table s.t alter column a set not null;"},
+		{name: "drop not null", old: table(column(notNull, defaultValue)), new: table(column(defaultValue)), expected: "alter table s.t alter column a drop not null;"},
+		{name: "keep constraints", old: table(column(notNull, defaultValue), named("t_a_key", unique("a"))), new: table(column(notNull, defaultValue), named("t_a_key", unique("a")))},
+		{
+			name: "column unique",
+			old:  table(column()),
+			new:  table(column(unnamed(&ConstraintUniqueExpr{}))),
+			err:  "constraints of column `s.t.a` cannot be altered: a int unique",
+		},
+		{name: "add named constraint", old: table(column()), new: table(column(), named("t_a_key", unique("a"))), expected: "alter table s.t add constraint t_a_key unique (a);"},
+		{name: "drop named constraint", old: table(column(), named("t_a_key", unique("a"))), new: table(column()), expected: "alter table s.t drop constraint t_a_key;"},
+		{
+			name: "change named constraint",
+			old:  table(column(), named("t_key", unique("a"))),
+			new:  table(column(notNull), named("t_key", &ConstraintWithColumns{Columns: []string{"a"}, Constraint: unnamed(&ConstraintPrimaryKeyExpr{})})),
+			expected: "alter table s.t drop constraint t_key;\n" +
+				"alter table s.t alter column a set not null;\n" +
+				"alter table s.t add constraint t_key primary key (a);",
+		},
+		{name: "add unnamed constraint", old: table(column()), new: table(column(), unique("a")), err: "unnamed constraints of table `s.t` cannot be altered"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var result, err = DiffSchemas(test.old, test.new)
+			if err != nil {
+				if err.Error() != test.err {
+					t.Errorf("unexpected error: %s", err)
+				}
+				return
+			}
+			if test.err != "" {
+				t.Errorf("expected error: %s", test.err)
+			}
+			if actual := result.String(); actual != test.expected {
+				t.Errorf("DiffSchemas() = %q, expected %q", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestDiffStatements(t *testing.T) {
+	var (
+		table = func(name, dataType string) SqlStmt {
+			var body = &TableBodyDescriber{}
+			body.AddField(&SqlField{Name: &Literal{Text: "a"}, Describer: &DataTypeExpr{DataType: dataType}})
+			return &CreateStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: name}, Create: body}
+		}
+		query = func(column string) SqlStmt {
+			return &SelectStmt{Columns: []SqlExpr{&Literal{Text: column}}, From: QualifiedTable("s", "t")}
+		}
+	)
+	var tests = []struct {
+		name     string
+		old, new []SqlStmt
+		expected []string
+	}{
+		{name: "equal", old: []SqlStmt{table("t", "int"), query("a")}, new: []SqlStmt{table("t", "int"), query("a")}},
+		{name: "added", old: []SqlStmt{table("t", "int")}, new: []SqlStmt{table("t", "int"), table("u", "int")}, expected: []string{"+ create table s.u (\n\ta int\n)"}},
+		{name: "removed", old: []SqlStmt{table("t", "int"), table("u", "int")}, new: []SqlStmt{table("t", "int")}, expected: []string{"- create table s.u (\n\ta int\n)"}},
+		{
+			name:     "modified",
+			old:      []SqlStmt{table("t", "int")},
+			new:      []SqlStmt{table("t", "bigint")},
+			expected: []string{"- create table s.t (\n\ta int\n)\n+ create table s.t (\n\ta bigint\n)"},
+		},
+		{
+			name:     "resolve nothing",
+			old:      []SqlStmt{query("a")},
+			new:      []SqlStmt{query("b")},
+			expected: []string{"- select a from s.t where 1 = 1", "+ select b from s.t where 1 = 1"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var diffs = DiffStatements(test.old, test.new)
+			if len(diffs) != len(test.expected) {
+				t.Fatalf("DiffStatements() = %v, expected %q", diffs, test.expected)
+			}
+			for i, diff := range diffs {
+				if actual := diff.String(); actual != test.expected[i] {
+					t.Errorf("diff %d = %q, expected %q", i, actual, test.expected[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package sql_ast
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// StructuralDiff compares the statements structurally and returns the list of differing fields,
+// the result is empty if the statements are equal. See sqlasttest.Equal for the use in tests
+func StructuralDiff(expected, actual SqlStmt) string {
+	var diff = make([]string, 0)
+	diffValues("", reflect.ValueOf(expected), reflect.ValueOf(actual), &diff)
+	return strings.Join(diff, "\n")
+}
+
+func diffValues(path string, expected, actual reflect.Value, diff *[]string) {
+	if !expected.IsValid() || !actual.IsValid() {
+		if expected.IsValid() != actual.IsValid() {
+			*diff = append(*diff, formatDiff(path, expected, actual))
+		}
+		return
+	}
+	if expected.Type() != actual.Type() {
+		*diff = append(*diff, formatDiff(path, expected, actual))
+		return
+	}
+	switch expected.Kind() {
+	case reflect.Interface:
+		if expected.IsNil() || actual.IsNil() {
+			if expected.IsNil() != actual.IsNil() {
+				*diff = append(*diff, formatDiff(path, expected, actual))
+			}
+			return
+		}
+		diffValues(path, expected.Elem(), actual.Elem(), diff)
+	case reflect.Ptr:
+		if expected.IsNil() || actual.IsNil() {
+			if expected.IsNil() != actual.IsNil() {
+				*diff = append(*diff, formatDiff(path, expected, actual))
+			}
+			return
+		}
+		diffValues(path+fmt.Sprintf(".(%s)", expected.Type()), expected.Elem(), actual.Elem(), diff)
+	case reflect.Struct:
+		for i := 0; i < expected.NumField(); i++ {
+			// unexported fields cannot be compared by their values
+			if expected.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			diffValues(path+"."+expected.Type().Field(i).Name, expected.Field(i), actual.Field(i), diff)
+		}
+	case reflect.Slice, reflect.Array:
+		if expected.Len() != actual.Len() {
+			*diff = append(*diff, formatDiff(path, expected, actual))
+			return
+		}
+		for i := 0; i < expected.Len(); i++ {
+			diffValues(path+fmt.Sprintf("[%d]", i), expected.Index(i), actual.Index(i), diff)
+		}
+	default:
+		if !reflect.DeepEqual(expected.Interface(), actual.Interface()) {
+			*diff = append(*diff, formatDiff(path, expected, actual))
+		}
+	}
+}
+
+func formatDiff(path string, expected, actual reflect.Value) string {
+	var format = func(v reflect.Value) string {
+		if !v.IsValid() {
+			return "nil"
+		}
+		return fmt.Sprintf("%#v", v.Interface())
+	}
+	return fmt.Sprintf("  %s:\n\t- %s\n\t+ %s", path, format(expected), format(actual))
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/iv-menshenin/dragonfly/utils"
 	"go/token"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -84,12 +85,21 @@ type (
 	}
 )
 
+// GetName returns the local name only, use FullName to render the reference to the object
 func (c *Selector) GetName() string {
+	return c.Name
+}
+
+// FullName returns the name qualified with the container
+func (c *Selector) FullName() string {
+	if c.Container == "" {
+		return c.Name
+	}
 	return fmt.Sprintf("%s.%s", c.Container, c.Name)
 }
 
 func (c *Selector) String() string {
-	return c.GetName()
+	return c.FullName()
 }
 
 func (c *Selector) expression() int { return 0 }
@@ -98,6 +108,87 @@ func (c *Selector) dependedOn() Dependencies {
 	return dependedOn2(c.Container, c.Name)
 }
 
+type (
+	// Ident is the plain identifier, possibly qualified with the schema: `name` or `schema.name`
+	Ident string
+)
+
+var identPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*(\.[A-Za-z_][A-Za-z0-9_$]*)?$`)
+
+// NewIdent checks that the name is a valid identifier, quoting and whitespaces are not allowed
+func NewIdent(name string) (Ident, error) {
+	if !identPattern.MatchString(name) {
+		return "", fmt.Errorf("invalid identifier `%s`", name)
+	}
+	return Ident(name), nil
+}
+
+func (c Ident) GetName() string {
+	return string(c)
+}
+
+func (c Ident) Qualified() bool {
+	return strings.Contains(string(c), ".")
+}
+
+func (c Ident) Parts() []string {
+	return strings.Split(string(c), ".")
+}
+
+type (
+	ColumnRef struct {
+		Table  SqlIdent
+		Column SqlIdent
+	}
+)
+
+func (c *ColumnRef) String() string {
+	if c.Table == nil {
+		return c.Column.GetName()
+	}
+	return fullName(c.Table) + "." + c.Column.GetName()
+}
+
+func (c *ColumnRef) expression() int { return 0 }
+
+// dependedOn takes the qualifier for the table, if it is an alias it is resolved by the statement, see resolveAliases
+func (c *ColumnRef) dependedOn() Dependencies {
+	if c.Table == nil {
+		return nil
+	}
+	var table = objectDependencies(c.Table)[0]
+	return dependedOn3(table.Schema, table.Object, c.Column.GetName())
+}
+
+type (
+	AllColumnsExpr      struct{}
+	TableAllColumnsExpr struct {
+		Table SqlIdent
+	}
+)
+
+func (c *AllColumnsExpr) String() string {
+	return "*"
+}
+
+func (c *AllColumnsExpr) expression() int { return 0 }
+
+// dependedOn of the star is up to the FROM clause of the statement, the set of columns expands at runtime
+func (c *AllColumnsExpr) dependedOn() Dependencies {
+	return nil
+}
+
+func (c *TableAllColumnsExpr) String() string {
+	return fullName(c.Table) + ".*"
+}
+
+func (c *TableAllColumnsExpr) expression() int { return 0 }
+
+// dependedOn refers to the table only, the set of columns expands at runtime
+func (c *TableAllColumnsExpr) dependedOn() Dependencies {
+	return objectDependencies(c.Table)
+}
+
 type (
 	AlterAttributeExpr struct {
 		AttributeName string
@@ -136,6 +227,86 @@ func (c *SetDropExpr) dependedOn() Dependencies {
 	return nil
 }
 
+type (
+	RowLevelSecurityAction int
+	RowLevelSecurityExpr   struct {
+		Action RowLevelSecurityAction
+	}
+)
+
+const (
+	RowLevelSecurityEnable RowLevelSecurityAction = iota
+	RowLevelSecurityDisable
+	RowLevelSecurityForce
+	RowLevelSecurityNoForce
+)
+
+func (c RowLevelSecurityAction) String() string {
+	switch c {
+	case RowLevelSecurityDisable:
+		return "disable"
+	case RowLevelSecurityForce:
+		return "force"
+	case RowLevelSecurityNoForce:
+		return "no force"
+	default:
+		return "enable"
+	}
+}
+
+func (c *RowLevelSecurityExpr) String() string {
+	return c.Action.String() + " row level security"
+}
+
+func (c *RowLevelSecurityExpr) expression() int { return 0 }
+
+func (c *RowLevelSecurityExpr) dependedOn() Dependencies {
+	return nil
+}
+
+type (
+	ClusterOnExpr struct {
+		IndexName SqlIdent
+	}
+	WithoutClusterExpr struct{}
+)
+
+func (c *ClusterOnExpr) String() string {
+	return "cluster on " + fullName(c.IndexName)
+}
+
+func (c *ClusterOnExpr) expression() int { return 0 }
+
+func (c *ClusterOnExpr) dependedOn() Dependencies {
+	return objectDependencies(c.IndexName)
+}
+
+func (c *WithoutClusterExpr) String() string {
+	return "set without cluster"
+}
+
+func (c *WithoutClusterExpr) expression() int { return 0 }
+
+func (c *WithoutClusterExpr) dependedOn() Dependencies {
+	return nil
+}
+
+type (
+	StorageExpr struct {
+		Storage string
+	}
+)
+
+func (c *StorageExpr) String() string {
+	return "set storage " + strings.ToLower(c.Storage)
+}
+
+func (c *StorageExpr) expression() int { return 0 }
+
+func (c *StorageExpr) dependedOn() Dependencies {
+	return nil
+}
+
 type (
 	AddExpr struct {
 		Target     SqlTarget
@@ -160,7 +331,13 @@ func (c *AddExpr) String() string {
 
 func (c *AddExpr) expression() int { return 0 }
 
+// dependedOn of the column is its type if it is user-defined, the type is referred at the type level without the Field.
+// The Definition is either the type itself or the column (see SqlField) with the constraints,
+// the altered table is added by AlterStmt
 func (c *AddExpr) dependedOn() Dependencies {
+	if c.Definition == nil {
+		return nil
+	}
 	return c.Definition.dependedOn()
 }
 
@@ -186,8 +363,12 @@ func (c *AlterExpr) String() string {
 	if _, ok := c.Alter.(*DataTypeExpr); ok {
 		subTarget = "type"
 	}
-	if _, ok := c.Alter.(*Default); ok {
-		subTarget = "default"
+	if def, ok := c.Alter.(*Default); ok {
+		// `set default expr`, the empty Default drops it
+		if def.Default == nil {
+			return utils.NonEmptyStringsConcatSpaceSeparated("alter", c.Target, c.Name, "drop default")
+		}
+		subTarget = "set"
 	}
 	return utils.NonEmptyStringsConcatSpaceSeparated("alter", c.Target, c.Name, subTarget, c.Alter)
 }
@@ -198,6 +379,88 @@ func (c *AlterExpr) dependedOn() Dependencies {
 	return c.Alter.dependedOn()
 }
 
+type (
+	// AlterColumnTypeExpr is `alter column name type new_type [collate c] [using expr]`, collation is the part of the type
+	AlterColumnTypeExpr struct {
+		Column  SqlIdent
+		NewType *DataTypeExpr
+		Using   SqlExpr
+	}
+)
+
+func (c *AlterColumnTypeExpr) String() string {
+	var clauseUsing string
+	if c.Using != nil {
+		clauseUsing = "using " + c.Using.String()
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated("alter column", c.Column.GetName(), "type", c.NewType, clauseUsing)
+}
+
+func (c *AlterColumnTypeExpr) expression() int { return 0 }
+
+func (c *AlterColumnTypeExpr) dependedOn() Dependencies {
+	var result = c.NewType.dependedOn()
+	if c.Using != nil {
+		result = concatDependencies(result, c.Using.dependedOn())
+	}
+	return result
+}
+
+type (
+	// AddColumnExpr is `add column [if not exists] column_definition`.
+	// Types is used to refer to the unqualified user-defined type of the column, see TableBodyDescriber
+	AddColumnExpr struct {
+		Column      *SqlField
+		IfNotExists bool
+		Types       *TypeRegistry
+	}
+)
+
+func (c *AddColumnExpr) String() string {
+	var ifNotExistsExpr string
+	if c.IfNotExists {
+		ifNotExistsExpr = "if not exists"
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated("add column", ifNotExistsExpr, c.Column)
+}
+
+func (c *AddColumnExpr) expression() int { return 0 }
+
+// dependedOn of the column is its type and the references of its constraints, the altered table is added by AlterStmt
+func (c *AddColumnExpr) dependedOn() Dependencies {
+	var result = c.Column.dependedOn()
+	if c.Types != nil {
+		result = concatDependencies(result, c.Types.typeDependencies(c.Column.Describer))
+	}
+	return result
+}
+
+type (
+	// DropColumnExpr is `drop column [if exists] name [cascade]`, the dropped column is reported by AlterStmt.unsolved
+	DropColumnExpr struct {
+		Column   SqlIdent
+		IfExists bool
+		Cascade  bool
+	}
+)
+
+func (c *DropColumnExpr) String() string {
+	cascadeExpr, ifExistsExpr := "", ""
+	if c.IfExists {
+		ifExistsExpr = "if exists"
+	}
+	if c.Cascade {
+		cascadeExpr = "cascade"
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated("drop column", ifExistsExpr, c.Column.GetName(), cascadeExpr)
+}
+
+func (c *DropColumnExpr) expression() int { return 0 }
+
+func (c *DropColumnExpr) dependedOn() Dependencies {
+	return nil
+}
+
 type (
 	BinaryExpr struct {
 		Left  SqlExpr
@@ -229,6 +492,28 @@ func (c *UnaryExpr) dependedOn() Dependencies {
 	return nil
 }
 
+type (
+	NotExpr struct {
+		Expr SqlExpr
+	}
+)
+
+// String wraps the operand in parentheses unless it is a single term, `not a and b` is `(not a) and b`
+func (c *NotExpr) String() string {
+	switch c.Expr.(type) {
+	case *Literal, *ColumnRef, *Selector, *FncCall, *BracketBlock, *NotExpr, *True, *False,
+		*Integer, *String, *ParameterExpr, *FieldSelectExpr, *RowConstructorExpr:
+		return utils.NonEmptyStringsConcatSpaceSeparated("not", c.Expr)
+	}
+	return "not (" + c.Expr.String() + ")"
+}
+
+func (c *NotExpr) expression() int { return 0 }
+
+func (c *NotExpr) dependedOn() Dependencies {
+	return c.Expr.dependedOn()
+}
+
 type (
 	SchemaExpr struct {
 		SchemaName string
@@ -277,7 +562,10 @@ func (c *Default) String() string {
 func (c *Default) expression() int { return 0 }
 
 func (c *Default) dependedOn() Dependencies {
-	return nil
+	if c.Default == nil {
+		return nil
+	}
+	return c.Default.dependedOn()
 }
 
 type (
@@ -313,16 +601,28 @@ func (c *FncCall) String() string {
 	for _, arg := range c.Args {
 		argmStr = append(argmStr, arg.String())
 	}
-	return fmt.Sprintf("%s(%s)", c.Name.GetName(), strings.Join(argmStr, ", "))
+	return fmt.Sprintf("%s(%s)", fullName(c.Name), strings.Join(argmStr, ", "))
 }
 
 func (c *FncCall) expression() int { return 0 }
 
+var sequenceFunctions = []string{"nextval", "currval", "setval"}
+
 func (c *FncCall) dependedOn() Dependencies {
 	var result Dependencies
 	for _, a := range c.Args {
 		result = concatDependencies(result, a.dependedOn())
 	}
+	if len(c.Args) > 0 && utils.ArrayContainsCI(sequenceFunctions, c.Name.GetName()) {
+		// nextval('schema.seq') or nextval('schema.seq'::regclass) refers to the sequence by its name
+		var arg = c.Args[0]
+		if cast, ok := arg.(*TypeCastExpr); ok {
+			arg = cast.Operand
+		}
+		if seq, ok := arg.(*String); ok {
+			result = concatDependencies(result, objectDependencies(&Literal{Text: seq.X}))
+		}
+	}
 	return result
 }
 
@@ -368,3 +668,364 @@ func (c *NotNullClause) expression() int { return 0 }
 func (c *NotNullClause) dependedOn() Dependencies {
 	return nil
 }
+
+type (
+	AliasExpr struct {
+		Expr  SqlExpr
+		Alias string
+	}
+)
+
+func (c *AliasExpr) String() string {
+	var alias = Literal{Text: c.Alias}
+	return utils.NonEmptyStringsConcatSpaceSeparated(c.Expr, "as", alias.String())
+}
+
+func (c *AliasExpr) expression() int { return 0 }
+
+func (c *AliasExpr) dependedOn() Dependencies {
+	return c.Expr.dependedOn()
+}
+
+type (
+	// IntervalLiteral is `interval '7 days'` if Unit is empty,
+	// otherwise standard form `interval '7' day` or `interval '1-2' year to month`
+	IntervalLiteral struct {
+		Value string
+		Unit  string
+	}
+)
+
+func (c *IntervalLiteral) String() string {
+	var value = String{X: c.Value}
+	return utils.NonEmptyStringsConcatSpaceSeparated("interval", value.String(), strings.ToLower(c.Unit))
+}
+
+func (c *IntervalLiteral) expression() int { return 0 }
+
+func (c *IntervalLiteral) dependedOn() Dependencies {
+	return nil
+}
+
+type (
+	RowConstructorExpr struct {
+		Elements []SqlExpr
+		Explicit bool
+	}
+)
+
+func (c *RowConstructorExpr) String() string {
+	var elements = make([]string, 0, len(c.Elements))
+	for _, e := range c.Elements {
+		elements = append(elements, e.String())
+	}
+	if c.Explicit {
+		return fmt.Sprintf("row(%s)", strings.Join(elements, ", "))
+	}
+	return fmt.Sprintf("(%s)", strings.Join(elements, ", "))
+}
+
+func (c *RowConstructorExpr) expression() int { return 0 }
+
+func (c *RowConstructorExpr) dependedOn() Dependencies {
+	var result Dependencies
+	for _, e := range c.Elements {
+		result = concatDependencies(result, e.dependedOn())
+	}
+	return result
+}
+
+type (
+	// CastExpr is ANSI `cast(x as type)`
+	CastExpr struct {
+		Operand    SqlExpr
+		TargetType *DataTypeExpr
+	}
+	// TypeCastExpr is PostgreSQL shorthand `x::type`
+	TypeCastExpr struct {
+		Operand    SqlExpr
+		TargetType *DataTypeExpr
+	}
+)
+
+func (c *CastExpr) String() string {
+	return fmt.Sprintf("cast(%s as %s)", c.Operand, c.TargetType)
+}
+
+func (c *CastExpr) expression() int { return 0 }
+
+func (c *CastExpr) dependedOn() Dependencies {
+	return concatDependencies(c.Operand.dependedOn(), c.TargetType.dependedOn())
+}
+
+func (c *TypeCastExpr) String() string {
+	if _, ok := c.Operand.(*BinaryExpr); ok {
+		return fmt.Sprintf("(%s)::%s", c.Operand, c.TargetType)
+	}
+	return fmt.Sprintf("%s::%s", c.Operand, c.TargetType)
+}
+
+func (c *TypeCastExpr) expression() int { return 0 }
+
+func (c *TypeCastExpr) dependedOn() Dependencies {
+	return concatDependencies(c.Operand.dependedOn(), c.TargetType.dependedOn())
+}
+
+// ToCast converts PostgreSQL specific `x::type` to the standard form
+func (c *TypeCastExpr) ToCast() *CastExpr {
+	return &CastExpr{Operand: c.Operand, TargetType: c.TargetType}
+}
+
+type (
+	// ParameterExpr is a positional `$1` parameter if Name is empty, otherwise named `:name` parameter
+	ParameterExpr struct {
+		Position int
+		Name     string
+	}
+)
+
+func (c *ParameterExpr) String() string {
+	if c.Name != "" {
+		return ":" + c.Name
+	}
+	return "$" + strconv.Itoa(c.Position)
+}
+
+func (c *ParameterExpr) expression() int { return 0 }
+
+func (c *ParameterExpr) dependedOn() Dependencies {
+	return nil
+}
+
+type (
+	// AddValueExpr is `add value 'x' [before|after 'y']` for `alter type`.
+	// Note that before PostgreSQL 12 it cannot be executed inside a transaction block,
+	// and the new value cannot be used within the transaction that added it
+	AddValueExpr struct {
+		Value       string
+		Before      *string
+		After       *string
+		IfNotExists bool
+	}
+)
+
+func (c *AddValueExpr) String() string {
+	var ifNotExists, position string
+	if c.IfNotExists {
+		ifNotExists = "if not exists"
+	}
+	if c.Before != nil {
+		position = "before " + (&String{X: *c.Before}).String()
+	} else if c.After != nil {
+		position = "after " + (&String{X: *c.After}).String()
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated("add value", ifNotExists, (&String{X: c.Value}).String(), position)
+}
+
+func (c *AddValueExpr) expression() int { return 0 }
+
+func (c *AddValueExpr) dependedOn() Dependencies {
+	return nil
+}
+
+type (
+	// StringExpr is the raw SQL fragment, it is emitted as is and has no dependencies
+	StringExpr struct {
+		SQL string
+	}
+)
+
+func (c *StringExpr) String() string {
+	return c.SQL
+}
+
+func (c *StringExpr) expression() int { return 0 }
+
+func (c *StringExpr) dependedOn() Dependencies {
+	return nil
+}
+
+type (
+	// OrderByClause is `expr [asc|desc] [nulls first|last]`
+	OrderByClause struct {
+		Expr  SqlExpr
+		Desc  bool
+		Nulls string
+	}
+	// WindowSpec is the window definition used in OVER and WINDOW clauses
+	WindowSpec struct {
+		PartitionBy []SqlExpr
+		OrderBy     []OrderByClause
+		Frame       *FrameClause
+	}
+	// FrameClause is `rows|range|groups start` or `rows|range|groups between start and end`
+	FrameClause struct {
+		Mode  string
+		Start FrameBound
+		End   *FrameBound
+	}
+	// FrameBound kind is one of: unbounded preceding, preceding, current row, following, unbounded following.
+	// Offset is used with preceding and following only
+	FrameBound struct {
+		Kind   string
+		Offset SqlExpr
+	}
+	// OverExpr is the window function call, it refers to the named window if Window is nil
+	OverExpr struct {
+		Func       SqlExpr
+		Window     *WindowSpec
+		WindowName string
+	}
+)
+
+func (c *OrderByClause) String() string {
+	var direction, nulls string
+	if c.Desc {
+		direction = "desc"
+	}
+	if c.Nulls != "" {
+		nulls = "nulls " + strings.ToLower(c.Nulls)
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated(c.Expr, direction, nulls)
+}
+
+func (c *OrderByClause) expression() int { return 0 }
+
+func (c *OrderByClause) dependedOn() Dependencies {
+	return c.Expr.dependedOn()
+}
+
+func (c *WindowSpec) String() string {
+	var partitionBy, orderBy, frame string
+	if len(c.PartitionBy) > 0 {
+		partitionBy = "partition by " + joinExpressions(c.PartitionBy)
+	}
+	if len(c.OrderBy) > 0 {
+		var order = make([]string, 0, len(c.OrderBy))
+		for i := range c.OrderBy {
+			order = append(order, c.OrderBy[i].String())
+		}
+		orderBy = "order by " + strings.Join(order, ", ")
+	}
+	if c.Frame != nil {
+		frame = c.Frame.String()
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated(partitionBy, orderBy, frame)
+}
+
+func (c *WindowSpec) expression() int { return 0 }
+
+func (c *WindowSpec) dependedOn() Dependencies {
+	var result = expressionsDependencies(c.PartitionBy)
+	for i := range c.OrderBy {
+		result = concatDependencies(result, c.OrderBy[i].dependedOn())
+	}
+	if c.Frame != nil {
+		result = concatDependencies(result, c.Frame.dependedOn())
+	}
+	return result
+}
+
+func (c *FrameClause) String() string {
+	if c.End == nil {
+		return strings.ToLower(c.Mode) + " " + c.Start.String()
+	}
+	return fmt.Sprintf("%s between %s and %s", strings.ToLower(c.Mode), c.Start.String(), c.End.String())
+}
+
+func (c *FrameClause) expression() int { return 0 }
+
+func (c *FrameClause) dependedOn() Dependencies {
+	var result = c.Start.dependedOn()
+	if c.End != nil {
+		result = concatDependencies(result, c.End.dependedOn())
+	}
+	return result
+}
+
+func (c *FrameBound) String() string {
+	if c.Offset != nil {
+		return c.Offset.String() + " " + strings.ToLower(c.Kind)
+	}
+	return strings.ToLower(c.Kind)
+}
+
+func (c *FrameBound) expression() int { return 0 }
+
+func (c *FrameBound) dependedOn() Dependencies {
+	if c.Offset != nil {
+		return c.Offset.dependedOn()
+	}
+	return nil
+}
+
+func (c *OverExpr) String() string {
+	if c.Window == nil {
+		return fmt.Sprintf("%s over %s", c.Func.String(), c.WindowName)
+	}
+	return fmt.Sprintf("%s over (%s)", c.Func.String(), c.Window.String())
+}
+
+func (c *OverExpr) expression() int { return 0 }
+
+func (c *OverExpr) dependedOn() Dependencies {
+	var result = c.Func.dependedOn()
+	if c.Window != nil {
+		result = concatDependencies(result, c.Window.dependedOn())
+	}
+	return result
+}
+
+type (
+	// TablesampleClause is `tablesample method(percent)`, method is bernoulli or system
+	TablesampleClause struct {
+		Method  string
+		Percent SqlExpr
+	}
+)
+
+func (c *TablesampleClause) String() string {
+	return fmt.Sprintf("tablesample %s(%s)", strings.ToLower(c.Method), c.Percent.String())
+}
+
+func (c *TablesampleClause) expression() int { return 0 }
+
+func (c *TablesampleClause) dependedOn() Dependencies {
+	return c.Percent.dependedOn()
+}
+
+type (
+	// OverlapsExpr is `(start, end) overlaps (start, end)`
+	OverlapsExpr struct {
+		Left  [2]SqlExpr
+		Right [2]SqlExpr
+	}
+)
+
+func (c *OverlapsExpr) String() string {
+	return fmt.Sprintf("(%s, %s) overlaps (%s, %s)", c.Left[0], c.Left[1], c.Right[0], c.Right[1])
+}
+
+func (c *OverlapsExpr) expression() int { return 0 }
+
+func (c *OverlapsExpr) dependedOn() Dependencies {
+	return expressionsDependencies([]SqlExpr{c.Left[0], c.Left[1], c.Right[0], c.Right[1]})
+}
+
+type (
+	// FieldSelectExpr is the access to the field of the composite value `(arg).field`, the brackets are required
+	FieldSelectExpr struct {
+		Arg       SqlExpr
+		FieldName SqlIdent
+	}
+)
+
+func (c *FieldSelectExpr) String() string {
+	return fmt.Sprintf("(%s).%s", c.Arg, c.FieldName.GetName())
+}
+
+func (c *FieldSelectExpr) expression() int { return 0 }
+
+func (c *FieldSelectExpr) dependedOn() Dependencies {
+	return c.Arg.dependedOn()
+}
@@ -0,0 +1,49 @@
+package sql_ast
+
+import (
+	"go/token"
+	"testing"
+)
+
+func TestDropColumnExpr_String(t *testing.T) {
+	var tests = []struct {
+		expr     *DropColumnExpr
+		expected string
+	}{
+		{expr: &DropColumnExpr{Column: &Literal{Text: "c"}}, expected: "drop column c"},
+		{expr: &DropColumnExpr{Column: &Literal{Text: "c"}, IfExists: true}, expected: "drop column if exists c"},
+		{expr: &DropColumnExpr{Column: &Literal{Text: "c"}, Cascade: true}, expected: "drop column c cascade"},
+		{expr: &DropColumnExpr{Column: &Literal{Text: "c"}, IfExists: true, Cascade: true}, expected: "drop column if exists c cascade"},
+	}
+	for _, test := range tests {
+		if actual := test.expr.String(); actual != test.expected {
+			t.Errorf("String() = %q, expected %q", actual, test.expected)
+		}
+		if deps := test.expr.dependedOn(); len(deps) > 0 {
+			t.Errorf("dependedOn() = %v, expected none", deps)
+		}
+	}
+}
+
+func TestNotExpr_String(t *testing.T) {
+	var (
+		a = &Literal{Text: "a"}
+		b = &Literal{Text: "b"}
+	)
+	var tests = []struct {
+		expr     *NotExpr
+		expected string
+	}{
+		{expr: &NotExpr{Expr: a}, expected: "not a"},
+		{expr: &NotExpr{Expr: &True{}}, expected: "not true"},
+		{expr: &NotExpr{Expr: &NotExpr{Expr: a}}, expected: "not not a"},
+		{expr: &NotExpr{Expr: &BracketBlock{Expr: []SqlExpr{a}}}, expected: "not (\n\t a \n)"},
+		{expr: &NotExpr{Expr: &BinaryExpr{Left: a, Right: b, Op: token.LAND}}, expected: "not (a && b)"},
+		{expr: &NotExpr{Expr: &BinaryExpr{Left: a, Right: b, Op: token.EQL}}, expected: "not (a == b)"},
+	}
+	for _, test := range tests {
+		if actual := test.expr.String(); actual != test.expected {
+			t.Errorf("String() = %q, expected %q", actual, test.expected)
+		}
+	}
+}
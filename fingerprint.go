@@ -0,0 +1,23 @@
+package sql_ast
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+)
+
+// Fingerprint returns the hash of the statement that is the same for all statements
+// that differ only by literal values (strings, numbers and booleans).
+// The literal expressions of the copy of the statement are replaced with the placeholder, the rest is kept as is,
+// so that the type modifiers like varchar(10) still make the difference
+func Fingerprint(stmt SqlStmt) string {
+	var normalized = cloneNode(stmt).(SqlStmt)
+	replaceNodes(normalized, func(node interface{}) interface{} {
+		switch node.(type) {
+		case *Integer, *String, *True, *False:
+			return &Literal{Text: "?"}
+		}
+		return nil
+	})
+	var hash = sha1.Sum([]byte(normalized.String()))
+	return hex.EncodeToString(hash[:])
+}
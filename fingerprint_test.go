@@ -0,0 +1,57 @@
+package sql_ast
+
+import (
+	"go/token"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	var (
+		length = func(x int) *int { return &x }
+		table  = func(size int) SqlStmt {
+			return &CreateStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: "t"}, Create: (&TableBodyDescriber{}).AddField(
+				&SqlField{Name: &Literal{Text: "a"}, Describer: &DataTypeExpr{DataType: "varchar", Length: length(size)}},
+			)}
+		}
+		query = func(column string, value SqlExpr) SqlStmt {
+			return &SelectStmt{
+				Columns: []SqlExpr{&Literal{Text: column}},
+				From:    QualifiedTable("s", "t"),
+				Where:   &BinaryExpr{Left: &Literal{Text: column}, Right: value, Op: token.EQL},
+			}
+		}
+	)
+	var tests = []struct {
+		name  string
+		a, b  SqlStmt
+		equal bool
+	}{
+		{name: "integers", a: query("a", &Integer{X: 1}), b: query("a", &Integer{X: 2}), equal: true},
+		{name: "strings", a: query("a", &String{X: "x"}), b: query("a", &String{X: "it's"}), equal: true},
+		{name: "booleans", a: query("a", &True{}), b: query("a", &False{}), equal: true},
+		{name: "string and integer", a: query("a", &String{X: "1"}), b: query("a", &Integer{X: 1}), equal: true},
+		{name: "columns", a: query("a", &Integer{X: 1}), b: query("b", &Integer{X: 1})},
+		{name: "literal and column", a: query("a", &Integer{X: 1}), b: query("a", &Literal{Text: "b"})},
+		{name: "type length", a: table(10), b: table(255)},
+		{name: "same table", a: table(10), b: table(10), equal: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if equal := Fingerprint(test.a) == Fingerprint(test.b); equal != test.equal {
+				t.Errorf("fingerprints of\n%s\n%s\nare equal: %v, expected %v", test.a, test.b, equal, test.equal)
+			}
+		})
+	}
+}
+
+func TestFingerprint_keepsStatement(t *testing.T) {
+	var stmt = &SelectStmt{
+		Columns: []SqlExpr{&Literal{Text: "a"}},
+		From:    Table("t"),
+		Where:   &BinaryExpr{Left: &Literal{Text: "a"}, Right: &Integer{X: 1}, Op: token.EQL},
+	}
+	Fingerprint(stmt)
+	if actual := stmt.String(); actual != "select a from t where a == 1" {
+		t.Errorf("the statement is modified: %s", actual)
+	}
+}
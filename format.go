@@ -0,0 +1,114 @@
+package sql_ast
+
+import (
+	"github.com/iv-menshenin/dragonfly/utils"
+	"strconv"
+	"strings"
+)
+
+type (
+	Dialect interface {
+		QuoteIdent(name string) string
+		Placeholder(n int) string
+		SupportsConcurrently() bool
+	}
+	// Formatter is implemented by the nodes which rendering depends on the dialect
+	Formatter interface {
+		Format(d Dialect) string
+	}
+	PostgreSQLDialect struct{}
+	MySQLDialect      struct{}
+)
+
+func (c PostgreSQLDialect) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (c PostgreSQLDialect) Placeholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (c PostgreSQLDialect) SupportsConcurrently() bool {
+	return true
+}
+
+func (c MySQLDialect) QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (c MySQLDialect) Placeholder(int) string {
+	return "?"
+}
+
+func (c MySQLDialect) SupportsConcurrently() bool {
+	return false
+}
+
+// Format renders the statement for the dialect. The statement implementing Formatter renders itself,
+// otherwise it is rendered by formatStatement
+func Format(stmt SqlStmt, d Dialect) string {
+	if formatter, ok := stmt.(Formatter); ok {
+		return formatter.Format(d)
+	}
+	return formatStatement(stmt, d)
+}
+
+// formatStatement renders the copy of the statement by String, dialect-specific nodes of the copy are replaced
+// in post-order: reserved words are quoted, positional parameters are replaced with placeholders,
+// nested Formatter expressions are replaced with their output
+func formatStatement(stmt SqlStmt, d Dialect) string {
+	var result = cloneNode(stmt).(SqlStmt)
+	Walk(result, func(node interface{}) bool {
+		switch n := node.(type) {
+		case *Literal:
+			if utils.ArrayContainsCI(sqlReservedWords, n.Text) {
+				n.Text = d.QuoteIdent(n.Text)
+			}
+		case *InsertStmt:
+			for i, column := range n.Columns {
+				if utils.ArrayContainsCI(sqlReservedWords, column) {
+					n.Columns[i] = d.QuoteIdent(column)
+				}
+			}
+		}
+		return true
+	})
+	replaceNodes(result, func(node interface{}) interface{} {
+		switch n := node.(type) {
+		case SqlStmt:
+			// nested statements are rendered along with the enclosing one
+			return nil
+		case Formatter:
+			return &StringExpr{SQL: n.Format(d)}
+		case *ParameterExpr:
+			if n.Name == "" {
+				return &StringExpr{SQL: d.Placeholder(n.Position)}
+			}
+		}
+		return nil
+	})
+	return result.String()
+}
+
+func (c *SelectStmt) Format(d Dialect) string {
+	return formatStatement(c, d)
+}
+
+func (c *InsertStmt) Format(d Dialect) string {
+	return formatStatement(c, d)
+}
+
+func (c *UpdateStmt) Format(d Dialect) string {
+	return formatStatement(c, d)
+}
+
+func (c *WithStmt) Format(d Dialect) string {
+	return formatStatement(c, d)
+}
+
+// Format builds the index without concurrently if the dialect does not support it
+func (c *CreateIndexStmt) Format(d Dialect) string {
+	var index = *c
+	index.Concurrently = c.Concurrently && d.SupportsConcurrently()
+	return formatStatement(&index, d)
+}
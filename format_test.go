@@ -0,0 +1,68 @@
+package sql_ast
+
+import (
+	"go/token"
+	"testing"
+)
+
+var (
+	_ Dialect = PostgreSQLDialect{}
+	_ Dialect = MySQLDialect{}
+
+	_ Formatter = (*SelectStmt)(nil)
+	_ Formatter = (*InsertStmt)(nil)
+	_ Formatter = (*UpdateStmt)(nil)
+	_ Formatter = (*WithStmt)(nil)
+	_ Formatter = (*CreateIndexStmt)(nil)
+)
+
+func TestFormat(t *testing.T) {
+	var (
+		query = &SelectStmt{
+			Columns: []SqlExpr{&Literal{Text: "user"}},
+			From:    Table("t"),
+			Where:   &BinaryExpr{Left: &Literal{Text: "id"}, Right: &ParameterExpr{Position: 1}, Op: token.EQL},
+		}
+		index = &CreateIndexStmt{
+			Concurrently: true,
+			Name:         &Literal{Text: "i"},
+			Table:        &Literal{Text: "t"},
+			Keys:         []IndexKey{&ColumnIndexKey{Column: &Literal{Text: "a"}}},
+		}
+		raw = &SelectStmt{
+			Columns: []SqlExpr{&StringExpr{SQL: "`user`, \"order\""}},
+			From:    Table("t"),
+			Where:   &StringExpr{SQL: "id = $1 and name = ? and note = 'user'"},
+		}
+		insert = &InsertStmt{
+			Table:   Table("t"),
+			Columns: []string{"order", "a"},
+			Values:  [][]SqlExpr{{&ParameterExpr{Position: 1}, &ParameterExpr{Position: 2}}},
+		}
+	)
+	var tests = []struct {
+		name     string
+		stmt     SqlStmt
+		dialect  Dialect
+		expected string
+	}{
+		{name: "postgres select", stmt: query, dialect: PostgreSQLDialect{}, expected: `select "user" from t where id == $1`},
+		{name: "mysql select", stmt: query, dialect: MySQLDialect{}, expected: "select `user` from t where id == ?"},
+		{name: "postgres index", stmt: index, dialect: PostgreSQLDialect{}, expected: "create index concurrently i on t (a)"},
+		{name: "mysql index", stmt: index, dialect: MySQLDialect{}, expected: "create index i on t (a)"},
+		{name: "postgres insert", stmt: insert, dialect: PostgreSQLDialect{}, expected: `insert into t ("order", a) values ($1, $2)`},
+		{name: "postgres raw sql", stmt: raw, dialect: PostgreSQLDialect{}, expected: "select `user`, \"order\" from t where id = $1 and name = ? and note = 'user'"},
+		{name: "mysql raw sql", stmt: raw, dialect: MySQLDialect{}, expected: "select `user`, \"order\" from t where id = $1 and name = ? and note = 'user'"},
+		{name: "mysql insert", stmt: insert, dialect: MySQLDialect{}, expected: "insert into t (`order`, a) values (?, ?)"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := Format(test.stmt, test.dialect); actual != test.expected {
+				t.Errorf("Format() = %q, expected %q", actual, test.expected)
+			}
+		})
+	}
+	if query.String() != `select "user" from t where id == $1` || !index.Concurrently || insert.Columns[0] != "order" {
+		t.Error("the origin statement is modified")
+	}
+}
@@ -0,0 +1,414 @@
+package sql_ast
+
+import (
+	"fmt"
+	"go/token"
+)
+
+type (
+	LintWarning struct {
+		Code    string
+		Message string
+		// Pos is the clause of the statement where the problem is found, e.g. `update.where`
+		Pos string
+		Fix string
+	}
+	lintRule     func(stmt SqlStmt) []LintWarning
+	lintListRule func(stmts StatementList) []LintWarning
+)
+
+const (
+	LintAlwaysTrueWhere  = "always-true-where"
+	LintUnresolvedColumn = "unresolved-column"
+	LintUnusedCTE        = "unused-cte"
+	LintConcurrentlyInTx = "concurrently-in-transaction"
+	LintUndefinedWindow  = "undefined-window"
+	LintDroppedIndexed   = "dropped-indexed-column"
+)
+
+var lintRules = []lintRule{
+	lintAlwaysTrueWhere,
+	lintUnresolvedColumns,
+	lintUnusedCTEs,
+	lintUndefinedWindows,
+}
+
+var lintListRules = []lintListRule{
+	lintConcurrentlyInTransaction,
+	lintDroppedIndexedColumns,
+}
+
+// LintStatements checks each statement of the list and the order of the statements
+func LintStatements(stmts StatementList) []LintWarning {
+	var result = make([]LintWarning, 0)
+	for _, stmt := range stmts {
+		result = append(result, LintStatement(stmt)...)
+	}
+	for _, rule := range lintListRules {
+		result = append(result, rule(stmts)...)
+	}
+	return result
+}
+
+// LintStatement checks the statement for suspicious constructions, it does not modify the statement
+func LintStatement(stmt SqlStmt) []LintWarning {
+	var result = make([]LintWarning, 0)
+	for _, rule := range lintRules {
+		result = append(result, rule(stmt)...)
+	}
+	return result
+}
+
+func lintAlwaysTrueWhere(stmt SqlStmt) []LintWarning {
+	var result = make([]LintWarning, 0)
+	Walk(stmt, func(node interface{}) bool {
+		switch n := node.(type) {
+		case *SelectStmt:
+			if n.Where != nil && isAlwaysTrue(n.Where) {
+				result = append(result, LintWarning{
+					Code:    LintAlwaysTrueWhere,
+					Message: "condition `" + n.Where.String() + "` is always true",
+					Pos:     "select.where",
+					Fix:     "remove the condition",
+				})
+			}
+		case *UpdateStmt:
+			if n.Where == nil {
+				result = append(result, LintWarning{
+					Code:    LintAlwaysTrueWhere,
+					Message: "update without condition affects all rows of " + fullName(n.Table.Table),
+					Pos:     "update.where",
+					Fix:     "add the condition limiting the rows to be updated",
+				})
+			} else if isAlwaysTrue(n.Where) {
+				result = append(result, LintWarning{
+					Code:    LintAlwaysTrueWhere,
+					Message: "condition `" + n.Where.String() + "` is always true",
+					Pos:     "update.where",
+					Fix:     "add the condition limiting the rows to be updated",
+				})
+			}
+		}
+		return true
+	})
+	return result
+}
+
+func isAlwaysTrue(expr SqlExpr) bool {
+	switch e := SimplifyExpr(expr).(type) {
+	case *True:
+		return true
+	case *BracketBlock:
+		return len(e.Expr) == 1 && isAlwaysTrue(e.Expr[0])
+	case *BinaryExpr:
+		switch e.Op {
+		case token.LAND:
+			return isAlwaysTrue(e.Left) && isAlwaysTrue(e.Right)
+		case token.LOR:
+			return isAlwaysTrue(e.Left) || isAlwaysTrue(e.Right)
+		}
+		if l, ok := e.Left.(*Integer); ok {
+			if r, ok := e.Right.(*Integer); ok {
+				return compareConstants(e.Op, l.X-r.X)
+			}
+		}
+		if l, ok := e.Left.(*String); ok {
+			if r, ok := e.Right.(*String); ok {
+				switch {
+				case l.X < r.X:
+					return compareConstants(e.Op, -1)
+				case l.X > r.X:
+					return compareConstants(e.Op, 1)
+				default:
+					return compareConstants(e.Op, 0)
+				}
+			}
+		}
+	}
+	return false
+}
+
+// compareConstants checks the operator against the sign of the difference of two constants
+func compareConstants(op token.Token, diff int) bool {
+	switch op {
+	case token.EQL, token.ASSIGN:
+		return diff == 0
+	case token.NEQ:
+		return diff != 0
+	case token.LSS:
+		return diff < 0
+	case token.GTR:
+		return diff > 0
+	case token.LEQ:
+		return diff <= 0
+	case token.GEQ:
+		return diff >= 0
+	}
+	return false
+}
+
+func lintUnresolvedColumns(stmt SqlStmt) []LintWarning {
+	return unresolvedColumnsInScope(stmt, make(map[string]bool))
+}
+
+// unresolvedColumnsInScope checks the statement with the names of the enclosing statements. The correlated subqueries
+// of the expressions and the lateral FROM items see the names of the statement, the other FROM items see the outer ones
+func unresolvedColumnsInScope(stmt SqlStmt, outer map[string]bool) []LintWarning {
+	var (
+		result = make([]LintWarning, 0)
+		names  = make(map[string]bool, len(outer))
+		addAll = func(desc TableDesc) {
+			for name := range tableNames(desc) {
+				names[name] = true
+			}
+		}
+		nested = func(node interface{}, scope map[string]bool) {
+			Walk(node, func(node interface{}) bool {
+				if nested, ok := node.(SqlStmt); ok {
+					result = append(result, unresolvedColumnsInScope(nested, scope)...)
+					return false
+				}
+				return true
+			})
+		}
+		fromItem = func(desc TableDesc) {
+			if desc.Lateral {
+				nested(desc.Query, names)
+			} else {
+				nested(desc.Query, outer)
+			}
+		}
+	)
+	for name := range outer {
+		names[name] = true
+	}
+	switch n := stmt.(type) {
+	case *SelectStmt:
+		addAll(n.From)
+		for _, join := range n.Joins {
+			addAll(join.Table)
+		}
+		fromItem(n.From)
+		for _, join := range n.Joins {
+			fromItem(join.Table)
+			nested(join.On, names)
+		}
+		for _, col := range n.Columns {
+			result = append(result, unresolvedColumns(col, names, "select.columns")...)
+			nested(col, names)
+		}
+		if n.Where != nil {
+			result = append(result, unresolvedColumns(n.Where, names, "select.where")...)
+			nested(n.Where, names)
+		}
+		nested(n.Windows, names)
+	case *UpdateStmt:
+		addAll(n.Table)
+		for _, from := range n.From {
+			addAll(from)
+		}
+		for _, join := range n.Joins {
+			addAll(join.Table)
+		}
+		fromItem(n.Table)
+		for _, from := range n.From {
+			fromItem(from)
+		}
+		for _, join := range n.Joins {
+			fromItem(join.Table)
+			nested(join.On, names)
+		}
+		for _, set := range n.Set {
+			result = append(result, unresolvedColumns(set, names, "update.set")...)
+			nested(set, names)
+		}
+		if n.Where != nil {
+			result = append(result, unresolvedColumns(n.Where, names, "update.where")...)
+			nested(n.Where, names)
+		}
+	default:
+		Walk(stmt, func(node interface{}) bool {
+			if nested, ok := node.(SqlStmt); ok && nested != stmt {
+				result = append(result, unresolvedColumnsInScope(nested, names)...)
+				return false
+			}
+			return true
+		})
+	}
+	return result
+}
+
+// tableNames returns the names the table can be referred by, the alias hides the name of the table
+func tableNames(desc TableDesc) map[string]bool {
+	var names = make(map[string]bool)
+	if desc.Alias != "" {
+		names[desc.Alias] = true
+	} else if desc.Table != nil {
+		names[fullName(desc.Table)] = true
+		names[objectDependencies(desc.Table)[0].Object] = true
+	}
+	return names
+}
+
+// unresolvedColumns checks qualified column references, nested statements are skipped as they have their own scope
+func unresolvedColumns(expr SqlExpr, names map[string]bool, pos string) []LintWarning {
+	var result = make([]LintWarning, 0)
+	Walk(expr, func(node interface{}) bool {
+		var table SqlIdent
+		switch n := node.(type) {
+		case SqlStmt:
+			return false
+		case *ColumnRef:
+			table = n.Table
+		case *TableAllColumnsExpr:
+			table = n.Table
+		}
+		if table != nil && !names[fullName(table)] {
+			result = append(result, LintWarning{
+				Code:    LintUnresolvedColumn,
+				Message: "`" + fullName(table) + "` is not found in the FROM clause",
+				Pos:     pos,
+				Fix:     "add the table to the FROM clause or fix the qualifier",
+			})
+		}
+		return true
+	})
+	return result
+}
+
+// lintUnusedCTEs checks that each CTE is referenced by the main query or by another CTE
+func lintUnusedCTEs(stmt SqlStmt) []LintWarning {
+	var result = make([]LintWarning, 0)
+	Walk(stmt, func(node interface{}) bool {
+		with, ok := node.(*WithStmt)
+		if !ok {
+			return true
+		}
+		var used = make(map[string]bool)
+		for _, table := range CollectTables(&with.Select) {
+			used[table.Object] = used[table.Object] || table.Schema == ""
+		}
+		for i, cte := range with.CTEs {
+			for _, table := range CollectTables(&with.CTEs[i].Query) {
+				if table.Object != cte.Name {
+					used[table.Object] = used[table.Object] || table.Schema == ""
+				}
+			}
+		}
+		for _, cte := range with.CTEs {
+			if !used[cte.Name] {
+				result = append(result, LintWarning{
+					Code:    LintUnusedCTE,
+					Message: "common table expression `" + cte.Name + "` is never used",
+					Pos:     "with." + cte.Name,
+					Fix:     "remove the common table expression",
+				})
+			}
+		}
+		return true
+	})
+	return result
+}
+
+func lintConcurrentlyInTransaction(stmts StatementList) []LintWarning {
+	var (
+		result        = make([]LintWarning, 0)
+		inTransaction bool
+	)
+	for i, stmt := range stmts {
+		switch n := stmt.(type) {
+		case *BeginStmt:
+			inTransaction = true
+		case *CommitStmt:
+			inTransaction = false
+		case *CreateIndexStmt:
+			if n.Concurrently && inTransaction {
+				result = append(result, LintWarning{
+					Code:    LintConcurrentlyInTx,
+					Message: "create index concurrently cannot run inside a transaction block",
+					Pos:     fmt.Sprintf("statements[%d]", i),
+					Fix:     "move the statement out of the transaction",
+				})
+			}
+		}
+	}
+	return result
+}
+
+// lintUndefinedWindows checks that the windows referred by OVER clauses are defined by the WINDOW clause of the same query
+func lintUndefinedWindows(stmt SqlStmt) []LintWarning {
+	var result = make([]LintWarning, 0)
+	Walk(stmt, func(node interface{}) bool {
+		query, ok := node.(*SelectStmt)
+		if !ok {
+			return true
+		}
+		var defined = make(map[string]bool, len(query.Windows))
+		for _, window := range query.Windows {
+			defined[window.Name] = true
+		}
+		for _, col := range query.Columns {
+			Walk(col, func(node interface{}) bool {
+				switch n := node.(type) {
+				case SqlStmt:
+					return false
+				case *OverExpr:
+					if n.Window == nil && !defined[n.WindowName] {
+						result = append(result, LintWarning{
+							Code:    LintUndefinedWindow,
+							Message: "window `" + n.WindowName + "` is not defined",
+							Pos:     "select.columns",
+							Fix:     "add the window to the WINDOW clause or fix the name",
+						})
+					}
+				}
+				return true
+			})
+		}
+		return true
+	})
+	return result
+}
+
+// lintDroppedIndexedColumns checks that the columns dropped by the statements are not used by the indexes of the list.
+// The names are resolved by objectDependencies, the unqualified table matches the table of any schema
+func lintDroppedIndexedColumns(stmts StatementList) []LintWarning {
+	type indexedColumn struct {
+		schema string
+		index  SqlIdent
+	}
+	var (
+		result  = make([]LintWarning, 0)
+		indexed = make(map[NamedObject][]indexedColumn)
+	)
+	for _, stmt := range stmts {
+		if index, ok := stmt.(*CreateIndexStmt); ok {
+			for _, dep := range MergeDependencies(index.dependedOn(), nil) {
+				if dep.Field != "" {
+					var column = NamedObject{Object: dep.Object, Field: dep.Field}
+					indexed[column] = append(indexed[column], indexedColumn{schema: dep.Schema, index: index.Name})
+				}
+			}
+		}
+	}
+	for i, stmt := range stmts {
+		for _, obj := range stmt.unsolved() {
+			for _, column := range indexed[NamedObject{Object: obj.Object, Field: obj.Field}] {
+				if column.schema != obj.Schema && column.schema != "" && obj.Schema != "" {
+					continue
+				}
+				var index = "unnamed index"
+				if column.index != nil {
+					index = "index `" + column.index.GetName() + "`"
+				}
+				result = append(result, LintWarning{
+					Code:    LintDroppedIndexed,
+					Message: "column `" + obj.Field + "` of `" + obj.Object + "` is used by " + index,
+					Pos:     fmt.Sprintf("statements[%d]", i),
+					Fix:     "drop the index or keep the column",
+				})
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,163 @@
+package sql_ast
+
+import (
+	"go/token"
+	"testing"
+)
+
+func lintCodes(warnings []LintWarning) []string {
+	var codes = make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		codes = append(codes, warning.Code)
+	}
+	return codes
+}
+
+func TestLintStatements_droppedIndexedColumn(t *testing.T) {
+	var (
+		index = &CreateIndexStmt{
+			Name:  &Literal{Text: "t_c_idx"},
+			Table: &Literal{Text: "t"},
+			Keys:  []IndexKey{&ColumnIndexKey{Column: &Literal{Text: "c"}}},
+		}
+		dropColumn = func(table SqlIdent, column string) SqlStmt {
+			return &AlterStmt{Target: TargetTable, Name: table, Alter: &DropColumnExpr{Column: &Literal{Text: column}}}
+		}
+	)
+	var tests = []struct {
+		name     string
+		stmts    StatementList
+		expected int
+	}{
+		{name: "indexed column", stmts: StatementList{index, dropColumn(&Literal{Text: "t"}, "c")}, expected: 1},
+		{name: "indexed column of qualified table", stmts: StatementList{index, dropColumn(&Selector{Container: "s", Name: "t"}, "c")}, expected: 1},
+		{name: "not indexed column", stmts: StatementList{index, dropColumn(&Literal{Text: "t"}, "d")}},
+		{name: "column of another table", stmts: StatementList{index, dropColumn(&Literal{Text: "u"}, "c")}},
+		{
+			name: "index of another schema",
+			stmts: StatementList{
+				&CreateIndexStmt{Table: &Selector{Container: "a", Name: "t"}, Keys: []IndexKey{&ColumnIndexKey{Column: &Literal{Text: "c"}}}},
+				dropColumn(&Selector{Container: "b", Name: "t"}, "c"),
+			},
+		},
+		{
+			name: "drop expression",
+			stmts: StatementList{
+				index,
+				&AlterStmt{Target: TargetTable, Name: &Literal{Text: "t"}, Alter: &DropExpr{Target: TargetColumn, Name: &Literal{Text: "c"}}},
+			},
+			expected: 1,
+		},
+		{
+			name: "unqualified alter removing nothing",
+			stmts: StatementList{
+				index,
+				&AlterStmt{Target: TargetTable, Name: &Literal{Text: "t"}, Alter: &AddColumnExpr{
+					Column: &SqlField{Name: &Literal{Text: "c"}, Describer: &DataTypeExpr{DataType: "text"}},
+				}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var count int
+			for _, code := range lintCodes(LintStatements(test.stmts)) {
+				if code == LintDroppedIndexed {
+					count++
+				}
+			}
+			if count != test.expected {
+				t.Errorf("got %d %s warnings, expected %d", count, LintDroppedIndexed, test.expected)
+			}
+		})
+	}
+}
+
+func TestLintStatement_unresolvedColumnScope(t *testing.T) {
+	var (
+		column = func(table, name string) SqlExpr {
+			return &ColumnRef{Table: &Literal{Text: table}, Column: &Literal{Text: name}}
+		}
+		subquery = func(query *SelectStmt) SqlExpr {
+			return &BracketBlock{Statement: query}
+		}
+	)
+	var tests = []struct {
+		name     string
+		stmt     SqlStmt
+		expected int
+	}{
+		{
+			name: "correlated subquery",
+			stmt: &SelectStmt{
+				Columns: []SqlExpr{column("a", "x")},
+				From:    Table("a"),
+				Where: &FncCall{Name: &Literal{Text: "exists"}, Args: []SqlExpr{subquery(&SelectStmt{
+					Columns: []SqlExpr{&Integer{X: 1}},
+					From:    Table("b"),
+					Where:   &BinaryExpr{Left: column("b", "y"), Right: column("a", "x"), Op: token.EQL},
+				})}},
+			},
+		},
+		{
+			name: "lateral join",
+			stmt: &SelectStmt{
+				Columns: []SqlExpr{column("l", "x")},
+				From:    Table("a").WithAlias("t"),
+				Joins: []JoinClause{{
+					Kind:  "cross",
+					Table: TableDesc{Query: subquery(&SelectStmt{Columns: []SqlExpr{column("t", "x")}}), Alias: "l", Lateral: true},
+				}},
+			},
+		},
+		{
+			name: "not lateral join",
+			stmt: &SelectStmt{
+				Columns: []SqlExpr{column("q", "id")},
+				From:    Table("o"),
+				Joins: []JoinClause{{
+					Kind:  "cross",
+					Table: TableDesc{Query: subquery(&SelectStmt{Columns: []SqlExpr{column("o", "id")}, From: Table("i")}), Alias: "q"},
+				}},
+			},
+			expected: 1,
+		},
+		{
+			name: "not lateral subquery of correlated subquery",
+			stmt: &SelectStmt{
+				Columns: []SqlExpr{column("a", "x")},
+				From:    Table("a"),
+				Where: &FncCall{Name: &Literal{Text: "exists"}, Args: []SqlExpr{subquery(&SelectStmt{
+					Columns: []SqlExpr{&Integer{X: 1}},
+					From:    TableDesc{Query: subquery(&SelectStmt{Columns: []SqlExpr{column("a", "x")}, From: Table("b")}), Alias: "q"},
+				})}},
+			},
+		},
+		{
+			name: "inner tables are not visible outside",
+			stmt: &SelectStmt{
+				Columns: []SqlExpr{column("b", "y"), subquery(&SelectStmt{Columns: []SqlExpr{column("b", "y")}, From: Table("b")})},
+				From:    Table("a"),
+			},
+			expected: 1,
+		},
+		{
+			name:     "unknown qualifier of subquery",
+			stmt:     &SelectStmt{Columns: []SqlExpr{subquery(&SelectStmt{Columns: []SqlExpr{column("c", "y")}, From: Table("b")})}, From: Table("a")},
+			expected: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var count int
+			for _, code := range lintCodes(LintStatement(test.stmt)) {
+				if code == LintUnresolvedColumn {
+					count++
+				}
+			}
+			if count != test.expected {
+				t.Errorf("got %d %s warnings, expected %d", count, LintUnresolvedColumn, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,395 @@
+package sql_ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+type (
+	Rewriter struct {
+		Statements StatementList
+	}
+)
+
+func NewRewriter(stmts ...SqlStmt) *Rewriter {
+	return &Rewriter{Statements: stmts}
+}
+
+// RenameTable returns a copy of the statements where all the references to the table are replaced with the new name.
+// Unqualified names are matched only if oldSchema is empty
+func (r *Rewriter) RenameTable(oldSchema, oldName, newSchema, newName string) StatementList {
+	var result = r.clone()
+	for _, stmt := range result {
+		var aliases = collectAliases(stmt)
+		walkTableIdents(stmt, func(ident *SqlIdent, qualifier bool) {
+			if qualifier && aliases[fullName(*ident)] {
+				return
+			}
+			if isObject(*ident, oldSchema, oldName) {
+				*ident = objectIdent(newSchema, newName)
+			}
+		})
+	}
+	return result
+}
+
+func (r *Rewriter) clone() StatementList {
+	var result = make(StatementList, 0, len(r.Statements))
+	for _, stmt := range r.Statements {
+		result = append(result, cloneNode(stmt).(SqlStmt))
+	}
+	return result
+}
+
+// walkTableIdents calls fn for each identifier that refers to a table,
+// qualifier is set for the identifiers that qualify columns, they can refer to an alias
+func walkTableIdents(node interface{}, fn func(ident *SqlIdent, qualifier bool)) {
+	Walk(node, func(node interface{}) bool {
+		switch n := node.(type) {
+		case *TableDesc:
+			if n.Table != nil {
+				fn(&n.Table, false)
+			}
+		case *ColumnRef:
+			if n.Table != nil {
+				fn(&n.Table, true)
+			}
+		case *TableAllColumnsExpr:
+			fn(&n.Table, true)
+		case *ConstraintForeignKeyExpr:
+			fn(&n.ToTable, false)
+		case *CreateStmt:
+			if n.Target == TargetTable {
+				fn(&n.Name, false)
+			}
+		case *AlterStmt:
+			if n.Target == TargetTable {
+				fn(&n.Name, false)
+			}
+		case *DropStmt:
+			if n.Target == TargetTable {
+				fn(&n.Name, false)
+			}
+		case *CreateIndexStmt:
+			fn(&n.Table, false)
+		case *CreateRuleStmt:
+			fn(&n.Table, false)
+		case *CreatePolicyStmt:
+			fn(&n.Table, false)
+		case *AlterPolicyStmt:
+			fn(&n.Table, false)
+		case *DropPolicyStmt:
+			fn(&n.Table, false)
+		case *TableBodyDescriber:
+			for i := range n.Inherits {
+				fn(&n.Inherits[i], false)
+			}
+		case *PartitionOfClause:
+			fn(&n.Parent, false)
+		case *CreatePublicationStmt:
+			for i := range n.Tables {
+				fn(&n.Tables[i], false)
+			}
+		case *GrantStmt:
+			if n.Target == TargetTable {
+				for i := range n.Objects {
+					fn(&n.Objects[i].Name, false)
+				}
+			}
+		}
+		return true
+	})
+}
+
+// collectCTEs returns the names of the common table expressions, they are referred without the schema
+func collectCTEs(node interface{}) map[string]bool {
+	var names = make(map[string]bool)
+	Walk(node, func(node interface{}) bool {
+		if with, ok := node.(*WithStmt); ok {
+			for _, cte := range with.CTEs {
+				names[cte.Name] = true
+			}
+		}
+		return true
+	})
+	return names
+}
+
+func collectAliases(node interface{}) map[string]bool {
+	var aliases = make(map[string]bool)
+	Walk(node, func(node interface{}) bool {
+		if table, ok := node.(*TableDesc); ok && table.Alias != "" {
+			aliases[table.Alias] = true
+		}
+		return true
+	})
+	return aliases
+}
+
+func isObject(ident SqlIdent, schema, name string) bool {
+	var obj = objectDependencies(ident)[0]
+	return obj.Schema == schema && obj.Object == name
+}
+
+func objectIdent(schema, name string) SqlIdent {
+	if schema == "" {
+		return &Literal{Text: name}
+	}
+	return &Selector{Container: schema, Name: name}
+}
+
+// RenameColumn returns a copy of the statements where all the references to the column of the table are replaced
+// with the new name. Unqualified column references are renamed only if the table is the only one in the statement
+func (r *Rewriter) RenameColumn(schema, table, oldCol, newCol string) StatementList {
+	var result = r.clone()
+	for _, stmt := range result {
+		var (
+			scope  = make(map[string]SqlIdent)
+			tables = 0
+			owned  = false
+		)
+		Walk(stmt, func(node interface{}) bool {
+			if desc, ok := node.(*TableDesc); ok && desc.Table != nil {
+				tables++
+				owned = owned || isObject(desc.Table, schema, table)
+				if desc.Alias != "" {
+					scope[desc.Alias] = desc.Table
+				}
+			}
+			return true
+		})
+		var (
+			isTable = func(ident SqlIdent) bool {
+				if aliased, ok := scope[fullName(ident)]; ok {
+					ident = aliased
+				}
+				return isObject(ident, schema, table)
+			}
+			renameIdent = func(ident *SqlIdent) {
+				if *ident != nil && (*ident).GetName() == oldCol {
+					*ident = &Literal{Text: newCol}
+				}
+			}
+			renameStrings = func(names []string) {
+				for i := range names {
+					if names[i] == oldCol {
+						names[i] = newCol
+					}
+				}
+			}
+		)
+		Walk(stmt, func(node interface{}) bool {
+			switch n := node.(type) {
+			case *ColumnRef:
+				if (n.Table != nil && isTable(n.Table)) || (n.Table == nil && owned && tables == 1) {
+					renameIdent(&n.Column)
+				}
+			case *InsertStmt:
+				if isTable(n.Table.Table) {
+					renameStrings(n.Columns)
+					if n.OnConflict != nil {
+						renameSetColumns(n.OnConflict.Set, oldCol, newCol)
+						if block, ok := n.OnConflict.Cause.(*BracketBlock); ok {
+							renameSetColumns(block.Expr, oldCol, newCol)
+						}
+					}
+				}
+			case *UpdateStmt:
+				if isTable(n.Table.Table) {
+					renameSetColumns(n.Set, oldCol, newCol)
+				}
+			case *CreateStmt:
+				if n.Target == TargetTable && isObject(n.Name, schema, table) {
+					if body, ok := n.Create.(*TableBodyDescriber); ok {
+						for _, field := range body.Fields {
+							renameIdent(&field.Name)
+						}
+						for _, constraint := range body.Constraints {
+							Walk(constraint, func(node interface{}) bool {
+								if columns, ok := node.(*ConstraintWithColumns); ok {
+									renameStrings(columns.Columns)
+								}
+								return true
+							})
+						}
+					}
+				}
+			case *AlterStmt:
+				if n.Target == TargetTable && isObject(n.Name, schema, table) {
+					switch alter := n.Alter.(type) {
+					case *AddExpr:
+						renameIdent(&alter.Name)
+					case *AddColumnExpr:
+						renameIdent(&alter.Column.Name)
+					case *DropExpr:
+						renameIdent(&alter.Name)
+					case *DropColumnExpr:
+						renameIdent(&alter.Column)
+					case *AlterExpr:
+						renameIdent(&alter.Name)
+					case *SqlRename:
+						if alter.Target == TargetColumn {
+							renameIdent(&alter.OldName)
+						}
+					}
+				}
+			case *CreateIndexStmt:
+				if isObject(n.Table, schema, table) {
+					for _, key := range n.Keys {
+						if column, ok := key.(*ColumnIndexKey); ok {
+							renameIdent(&column.Column)
+						}
+					}
+					for i := range n.Include {
+						renameIdent(&n.Include[i])
+					}
+				}
+			case *ConstraintForeignKeyExpr:
+				if isObject(n.ToTable, schema, table) && n.ToColumn == oldCol {
+					n.ToColumn = newCol
+				}
+			}
+			return true
+		})
+	}
+	return result
+}
+
+// renameSetColumns renames the unqualified column names on the left side of `col = expr` and plain column lists,
+// they always refer to the target table even if the statement has other tables
+func renameSetColumns(exprs []SqlExpr, oldCol, newCol string) {
+	for _, expr := range exprs {
+		if binary, ok := expr.(*BinaryExpr); ok {
+			expr = binary.Left
+		}
+		switch column := expr.(type) {
+		case *Literal:
+			if column.GetName() == oldCol {
+				column.Text = newCol
+			}
+		case *ColumnRef:
+			if column.Table == nil && column.Column.GetName() == oldCol {
+				column.Column = &Literal{Text: newCol}
+			}
+		}
+	}
+}
+
+type (
+	SchemaQualifier struct {
+		DefaultSchema string
+	}
+)
+
+// Qualify returns a copy of the statement where all unqualified table references, the names of created,
+// altered or dropped objects and the user-defined types known by TypeRegistry are qualified with DefaultSchema
+func (q *SchemaQualifier) Qualify(stmt SqlStmt) SqlStmt {
+	var result = cloneNode(stmt).(SqlStmt)
+	q.qualify(result)
+	return result
+}
+
+func (q *SchemaQualifier) qualify(stmt SqlStmt) {
+	var (
+		aliases = collectAliases(stmt)
+		ctes    = collectCTEs(stmt)
+		qualify = func(ident *SqlIdent, qualifier bool) {
+			if (qualifier && aliases[fullName(*ident)]) || ctes[fullName(*ident)] {
+				return
+			}
+			if obj := objectDependencies(*ident)[0]; obj.Schema == "" {
+				*ident = objectIdent(q.DefaultSchema, obj.Object)
+			}
+		}
+	)
+	walkTableIdents(stmt, qualify)
+	Walk(stmt, func(node interface{}) bool {
+		var (
+			target SqlTarget
+			name   *SqlIdent
+		)
+		switch n := node.(type) {
+		case *TableBodyDescriber:
+			if n.Types != nil {
+				for _, field := range n.Fields {
+					n.Types.qualifyType(field.Describer, q.DefaultSchema)
+				}
+			}
+			return true
+		case *AddColumnExpr:
+			if n.Types != nil {
+				n.Types.qualifyType(n.Column.Describer, q.DefaultSchema)
+			}
+			return true
+		case *CreateStmt:
+			target, name = n.Target, &n.Name
+		case *AlterStmt:
+			target, name = n.Target, &n.Name
+		case *DropStmt:
+			target, name = n.Target, &n.Name
+		case *DropFunctionStmt:
+			target, name = TargetFunction, &n.Name
+		case *GrantStmt:
+			// the tables are qualified by walkTableIdents
+			if n.Target != TargetTable && n.Target != TargetSchema {
+				for i := range n.Objects {
+					qualify(&n.Objects[i].Name, false)
+				}
+			}
+			return true
+		default:
+			return true
+		}
+		switch target {
+		case TargetNone, TargetSchema, TargetTable, TargetColumn, TargetConstraint, TargetTablespace:
+		default:
+			qualify(name, false)
+		}
+		return true
+	})
+}
+
+// ResolveNames qualifies the unqualified names of the statements in place with defaultSchema (see SchemaQualifier)
+// and checks that each referenced object is created by the statements or assumed to exist.
+// The assumed object without Field covers all its fields. The columns qualified with aliases and CTE names are resolved
+// by the statements (see resolveAliases and WithStmt.dependedOn), the objects that are not in any schema
+// (e.g. tablespaces) are referred without the schema
+func ResolveNames(stmts []SqlStmt, defaultSchema string, assumed ...NamedObject) error {
+	var (
+		qualifier = SchemaQualifier{DefaultSchema: defaultSchema}
+		known     = make(map[NamedObject]bool)
+		objects   = make(map[NamedObject]bool)
+	)
+	for _, obj := range assumed {
+		known[obj] = true
+		if obj.Field == "" {
+			objects[obj] = true
+		}
+	}
+	for _, stmt := range stmts {
+		qualifier.qualify(stmt)
+		for _, obj := range stmt.solved() {
+			known[obj] = true
+		}
+	}
+	var unresolved = make([]string, 0)
+	for _, stmt := range stmts {
+		for _, dep := range stmt.dependedOn() {
+			if dep.Schema == "pg_catalog" || known[dep] || objects[NamedObject{Schema: dep.Schema, Object: dep.Object}] {
+				continue
+			}
+			var name = dep.Object
+			if dep.Schema != "" {
+				name = dep.Schema + "." + name
+			}
+			if dep.Field != "" {
+				name += "." + dep.Field
+			}
+			unresolved = append(unresolved, name)
+		}
+	}
+	if len(unresolved) > 0 {
+		return fmt.Errorf("unresolved references: %s", strings.Join(unresolved, ", "))
+	}
+	return nil
+}
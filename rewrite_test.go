@@ -0,0 +1,213 @@
+package sql_ast
+
+import (
+	"go/token"
+	"testing"
+)
+
+func TestRewriter_tableReferences(t *testing.T) {
+	var table = func() SqlIdent { return &Literal{Text: "t"} }
+	var tests = []struct {
+		name      string
+		stmt      SqlStmt
+		renamed   string
+		qualified string
+	}{
+		{
+			name:      "rule",
+			stmt:      &CreateRuleStmt{Name: &Literal{Text: "r"}, Event: "insert", Table: table(), Instead: true},
+			renamed:   "create rule r as on insert to s.n do instead nothing",
+			qualified: "create rule r as on insert to public.t do instead nothing",
+		},
+		{
+			name:      "create policy",
+			stmt:      &CreatePolicyStmt{Name: &Literal{Text: "p"}, Table: table()},
+			renamed:   "create policy p on s.n",
+			qualified: "create policy p on public.t",
+		},
+		{
+			name:      "alter policy",
+			stmt:      &AlterPolicyStmt{Name: &Literal{Text: "p"}, Table: table(), NewName: &Literal{Text: "q"}},
+			renamed:   "alter policy p on s.n rename to q",
+			qualified: "alter policy p on public.t rename to q",
+		},
+		{
+			name:      "drop policy",
+			stmt:      &DropPolicyStmt{Name: &Literal{Text: "p"}, Table: table()},
+			renamed:   "drop policy p on s.n",
+			qualified: "drop policy p on public.t",
+		},
+		{
+			name:      "inherits",
+			stmt:      &CreateStmt{Target: TargetTable, Name: &Literal{Text: "c"}, Create: &TableBodyDescriber{Inherits: []SqlIdent{table()}}},
+			renamed:   "create table c (\n) inherits (s.n)",
+			qualified: "create table public.c (\n) inherits (public.t)",
+		},
+		{
+			name: "partition of",
+			stmt: &CreateStmt{Target: TargetTable, Name: &Literal{Text: "c"}, Create: &TableBodyDescriber{
+				PartitionOf: &PartitionOfClause{Parent: table(), Bound: &PartitionListBound{In: []SqlExpr{&Integer{X: 1}}}},
+			}},
+			renamed:   "create table c partition of s.n for values in (1)",
+			qualified: "create table public.c partition of public.t for values in (1)",
+		},
+		{
+			name:      "publication",
+			stmt:      &CreatePublicationStmt{Name: "pub", Tables: []SqlIdent{table()}},
+			renamed:   "create publication pub for table s.n",
+			qualified: "create publication pub for table public.t",
+		},
+		{
+			name:      "grant",
+			stmt:      &GrantStmt{Privileges: []string{"select"}, Target: TargetTable, Objects: []GrantObject{{Name: table()}}, Grantees: []string{"u"}},
+			renamed:   "grant select on table s.n to u",
+			qualified: "grant select on table public.t to u",
+		},
+	}
+	var qualifier = SchemaQualifier{DefaultSchema: "public"}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var origin = test.stmt.String()
+			if actual := NewRewriter(test.stmt).RenameTable("", "t", "s", "n")[0].String(); actual != test.renamed {
+				t.Errorf("RenameTable() = %q, expected %q", actual, test.renamed)
+			}
+			if actual := qualifier.Qualify(test.stmt).String(); actual != test.qualified {
+				t.Errorf("Qualify() = %q, expected %q", actual, test.qualified)
+			}
+			if actual := test.stmt.String(); actual != origin {
+				t.Errorf("the origin is modified: %q", actual)
+			}
+		})
+	}
+}
+
+func TestResolveNames(t *testing.T) {
+	var (
+		createTable = func() SqlStmt {
+			return &CreateStmt{Target: TargetTable, Name: &Literal{Text: "t"}, Create: &TableBodyDescriber{
+				Fields: []*SqlField{{Name: &Literal{Text: "a"}, Describer: &DataTypeExpr{DataType: "integer"}}},
+			}}
+		}
+		indexOn = func(column string) SqlStmt {
+			return &CreateIndexStmt{Table: &Literal{Text: "t"}, Keys: []IndexKey{&ColumnIndexKey{Column: &Literal{Text: column}}}}
+		}
+		usersTable = func() SqlStmt {
+			return &CreateStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: "users"}, Create: &TableBodyDescriber{
+				Fields: []*SqlField{{Name: &Literal{Text: "id"}, Describer: &DataTypeExpr{DataType: "int"}}},
+			}}
+		}
+		selectUsers = func(column string) SqlStmt {
+			return &SelectStmt{
+				Columns: []SqlExpr{&ColumnRef{Table: &Literal{Text: "u"}, Column: &Literal{Text: column}}},
+				From:    QualifiedTable("s", "users").WithAlias("u"),
+			}
+		}
+	)
+	var tests = []struct {
+		name     string
+		stmts    []SqlStmt
+		assumed  []NamedObject
+		expected string
+	}{
+		{name: "created table", stmts: []SqlStmt{createTable(), indexOn("a")}},
+		{name: "created table without the column", stmts: []SqlStmt{createTable(), indexOn("b")}, expected: "unresolved references: public.t.b"},
+		{name: "assumed table", stmts: []SqlStmt{indexOn("b")}, assumed: []NamedObject{{Schema: "public", Object: "t"}}},
+		{
+			name:     "assumed column",
+			stmts:    []SqlStmt{indexOn("b")},
+			assumed:  []NamedObject{{Schema: "public", Object: "t", Field: "a"}},
+			expected: "unresolved references: public.t, public.t.b",
+		},
+		{
+			name:     "policy table",
+			stmts:    []SqlStmt{&CreatePolicyStmt{Name: &Literal{Text: "p"}, Table: &Literal{Text: "u"}}},
+			expected: "unresolved references: public.u",
+		},
+		{
+			name: "common table expression",
+			stmts: []SqlStmt{createTable(), &WithStmt{
+				CTEs: []CommonTableExpr{{Name: "c", Query: SelectStmt{Columns: []SqlExpr{&Literal{Text: "a"}}, From: Table("t")}}},
+				Select: SelectStmt{
+					Columns: []SqlExpr{&Literal{Text: "a"}},
+					From:    Table("c"),
+				},
+			}},
+		},
+		{
+			name:  "aliased query",
+			stmts: []SqlStmt{usersTable(), selectUsers("id")},
+		},
+		{
+			name:     "aliased query with unknown column",
+			stmts:    []SqlStmt{usersTable(), selectUsers("name")},
+			expected: "unresolved references: s.users.name",
+		},
+		{
+			name: "qualified column of common table expression",
+			stmts: []SqlStmt{createTable(), &WithStmt{
+				CTEs: []CommonTableExpr{{Name: "c", Query: SelectStmt{Columns: []SqlExpr{&Literal{Text: "a"}}, From: Table("t")}}},
+				Select: SelectStmt{
+					Columns: []SqlExpr{&ColumnRef{Table: &Literal{Text: "c"}, Column: &Literal{Text: "a"}}},
+					From:    Table("c"),
+				},
+			}},
+		},
+		{
+			name: "tablespace",
+			stmts: []SqlStmt{&CreateStmt{Target: TargetTable, Name: &Literal{Text: "t"}, Create: &TableBodyDescriber{
+				Tablespace: &Literal{Text: "fast"},
+			}}},
+			expected: "unresolved references: fast",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var actual string
+			if err := ResolveNames(test.stmts, "public", test.assumed...); err != nil {
+				actual = err.Error()
+			}
+			if actual != test.expected {
+				t.Errorf("ResolveNames() = %q, expected %q", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestRewriter_RenameColumnOfUpdate(t *testing.T) {
+	var (
+		column = func(table SqlIdent, name string) *ColumnRef {
+			return &ColumnRef{Table: table, Column: &Literal{Text: name}}
+		}
+		set = func(left SqlExpr, right SqlExpr) SqlExpr {
+			return &BinaryExpr{Left: left, Right: right, Op: token.ASSIGN}
+		}
+	)
+	var tests = []struct {
+		name     string
+		stmt     *UpdateStmt
+		expected string
+	}{
+		{
+			name:     "literal target",
+			stmt:     &UpdateStmt{Table: QualifiedTable("s", "t"), Set: []SqlExpr{set(&Literal{Text: "a"}, &Integer{X: 1})}},
+			expected: "update s.t set b = 1 where 1 = 1",
+		},
+		{
+			name: "column target with from",
+			stmt: &UpdateStmt{
+				Table: QualifiedTable("s", "t"),
+				From:  []TableDesc{Table("u")},
+				Set:   []SqlExpr{set(column(nil, "a"), column(&Literal{Text: "u"}, "a"))},
+				Where: &BinaryExpr{Left: column(&Selector{Container: "s", Name: "t"}, "a"), Right: column(&Literal{Text: "u"}, "id"), Op: token.EQL},
+			},
+			expected: "update s.t set b = u.a from u where s.t.b == u.id",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := NewRewriter(test.stmt).RenameColumn("s", "t", "a", "b")[0].String(); actual != test.expected {
+				t.Errorf("RenameColumn() = %q, expected %q", actual, test.expected)
+			}
+		})
+	}
+}
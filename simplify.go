@@ -0,0 +1,83 @@
+package sql_ast
+
+import (
+	"go/token"
+)
+
+// SimplifyExpr removes boolean constants from the logical expressions and double negations
+// repeatedly until nothing can be simplified. The origin expression is not modified
+func SimplifyExpr(expr SqlExpr) SqlExpr {
+	for {
+		simplified, changed := simplifyExpr(expr)
+		if !changed {
+			return simplified
+		}
+		expr = simplified
+	}
+}
+
+func simplifyExpr(expr SqlExpr) (SqlExpr, bool) {
+	switch e := expr.(type) {
+	case *NotExpr:
+		var inner, changed = simplifyExpr(e.Expr)
+		switch i := inner.(type) {
+		case *NotExpr:
+			return i.Expr, true
+		case *True:
+			return &False{}, true
+		case *False:
+			return &True{}, true
+		}
+		if changed {
+			return &NotExpr{Expr: inner}, true
+		}
+	case *BinaryExpr:
+		if e.Op != token.LAND && e.Op != token.LOR {
+			return expr, false
+		}
+		var (
+			left, leftChanged   = simplifyExpr(e.Left)
+			right, rightChanged = simplifyExpr(e.Right)
+		)
+		// x and true = x, x and false = false, x or false = x, x or true = true
+		var neutral, absorbing = isTrueExpr, isFalseExpr
+		if e.Op == token.LOR {
+			neutral, absorbing = isFalseExpr, isTrueExpr
+		}
+		switch {
+		case absorbing(left):
+			return left, true
+		case absorbing(right):
+			return right, true
+		case neutral(left):
+			return right, true
+		case neutral(right):
+			return left, true
+		}
+		if leftChanged || rightChanged {
+			return &BinaryExpr{Left: left, Right: right, Op: e.Op}, true
+		}
+	case *BracketBlock:
+		if len(e.Expr) != 1 {
+			return expr, false
+		}
+		var inner, changed = simplifyExpr(e.Expr[0])
+		if isTrueExpr(inner) || isFalseExpr(inner) {
+			return inner, true
+		}
+		if changed {
+			return &BracketBlock{Expr: []SqlExpr{inner}}, true
+		}
+	}
+	return expr, false
+}
+
+func isTrueExpr(expr SqlExpr) bool {
+	_, ok := expr.(*True)
+	return ok
+}
+
+func isFalseExpr(expr SqlExpr) bool {
+	_, ok := expr.(*False)
+	return ok
+}
@@ -0,0 +1,46 @@
+package sql_ast
+
+import (
+	"go/token"
+	"testing"
+)
+
+func TestSimplifyExpr(t *testing.T) {
+	var (
+		x         = &ColumnRef{Column: &Literal{Text: "x"}}
+		y         = &ColumnRef{Column: &Literal{Text: "y"}}
+		and       = func(l, r SqlExpr) SqlExpr { return &BinaryExpr{Left: l, Right: r, Op: token.LAND} }
+		or        = func(l, r SqlExpr) SqlExpr { return &BinaryExpr{Left: l, Right: r, Op: token.LOR} }
+		not       = func(e SqlExpr) SqlExpr { return &NotExpr{Expr: e} }
+		brackets  = func(e SqlExpr) SqlExpr { return &BracketBlock{Expr: []SqlExpr{e}} }
+		trueExpr  = &True{}
+		falseExpr = &False{}
+	)
+	var tests = []struct {
+		name     string
+		expr     SqlExpr
+		expected SqlExpr
+	}{
+		{name: "x and true", expr: and(x, trueExpr), expected: x},
+		{name: "true and x", expr: and(trueExpr, x), expected: x},
+		{name: "x or false", expr: or(x, falseExpr), expected: x},
+		{name: "not not x", expr: not(not(x)), expected: x},
+		{name: "x and false", expr: and(x, falseExpr), expected: falseExpr},
+		{name: "x or true", expr: or(x, trueExpr), expected: trueExpr},
+		{name: "not true", expr: not(trueExpr), expected: falseExpr},
+		{name: "nested bracket block", expr: and(x, brackets(brackets(or(y, trueExpr)))), expected: x},
+		{name: "bracket block of simplified", expr: or(falseExpr, brackets(and(y, trueExpr))), expected: brackets(y)},
+		{name: "nothing to simplify", expr: and(x, or(y, not(x))), expected: and(x, or(y, not(x)))},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var origin = test.expr.String()
+			if actual := SimplifyExpr(test.expr).String(); actual != test.expected.String() {
+				t.Errorf("SimplifyExpr() = %q, expected %q", actual, test.expected.String())
+			}
+			if actual := test.expr.String(); actual != origin {
+				t.Errorf("the origin is modified: %q", actual)
+			}
+		})
+	}
+}
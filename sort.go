@@ -0,0 +1,294 @@
+package sql_ast
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TopologicalSort orders the statements so that each one follows the statements solving its dependencies.
+// The dependencies solved by none of the statements are considered existing, self-dependencies are ignored.
+// Independent statements keep their original order
+func TopologicalSort(stmts []SqlStmt) (StatementList, error) {
+	var order, rest = topologicalOrder(stmts)
+	if len(rest) > 0 {
+		var cycle = make([]string, 0, len(rest))
+		for _, i := range rest {
+			cycle = append(cycle, statementIdentity(stmts[i]))
+		}
+		return nil, fmt.Errorf("dependency cycle between statements: %s", strings.Join(cycle, ", "))
+	}
+	var result = make(StatementList, 0, len(stmts))
+	for _, i := range order {
+		result = append(result, stmts[i])
+	}
+	return result, nil
+}
+
+// topologicalOrder returns the indexes of the sorted statements and the indexes of the statements
+// that cannot be sorted because of the cycles, these are the statements of the cycles and the ones depending on them
+func topologicalOrder(stmts []SqlStmt) (order, rest []int) {
+	var (
+		followers = dependencyGraph(stmts)
+		inDegree  = make([]int, len(stmts))
+		done      = make([]bool, len(stmts))
+	)
+	for _, next := range followers {
+		for _, i := range next {
+			inDegree[i]++
+		}
+	}
+	for len(order) < len(stmts) {
+		var next = -1
+		for i := range stmts {
+			if !done[i] && inDegree[i] == 0 {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			break
+		}
+		done[next] = true
+		order = append(order, next)
+		for _, i := range followers[next] {
+			inDegree[i]--
+		}
+	}
+	for i := range stmts {
+		if !done[i] {
+			rest = append(rest, i)
+		}
+	}
+	return order, rest
+}
+
+// dependencyGraph returns the indexes of the statements following each statement, i.e. depending on it
+func dependencyGraph(stmts []SqlStmt) [][]int {
+	var (
+		solvedBy  = make(map[NamedObject][]int)
+		followers = make([][]int, len(stmts))
+	)
+	for i, stmt := range stmts {
+		for _, obj := range stmt.solved() {
+			solvedBy[obj] = append(solvedBy[obj], i)
+		}
+	}
+	for i, stmt := range stmts {
+		var preceding = make(map[int]bool)
+		for _, dep := range stmt.dependedOn() {
+			for _, j := range solvedBy[dep] {
+				if j != i && !preceding[j] {
+					preceding[j] = true
+					followers[j] = append(followers[j], i)
+				}
+			}
+		}
+	}
+	return followers
+}
+
+// cycles returns the number of the cycle for each statement of the graph, the statements of the same cycle
+// have the same number, -1 is for the statements that are not in any cycle (Tarjan's algorithm)
+func cycles(followers [][]int) []int {
+	var (
+		result  = make([]int, len(followers))
+		index   = make([]int, len(followers))
+		lowLink = make([]int, len(followers))
+		onStack = make([]bool, len(followers))
+		stack   = make([]int, 0)
+		counter = 0
+		cycle   = 0
+		visit   func(i int)
+	)
+	for i := range index {
+		index[i] = -1
+	}
+	visit = func(i int) {
+		index[i], lowLink[i] = counter, counter
+		counter++
+		stack = append(stack, i)
+		onStack[i] = true
+		for _, j := range followers[i] {
+			if index[j] < 0 {
+				visit(j)
+				if lowLink[j] < lowLink[i] {
+					lowLink[i] = lowLink[j]
+				}
+			} else if onStack[j] && index[j] < lowLink[i] {
+				lowLink[i] = index[j]
+			}
+		}
+		if lowLink[i] != index[i] {
+			return
+		}
+		var members = make([]int, 0)
+		for {
+			var j = stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[j] = false
+			members = append(members, j)
+			if j == i {
+				break
+			}
+		}
+		for _, j := range members {
+			result[j] = -1
+			if len(members) > 1 {
+				result[j] = cycle
+			}
+		}
+		if len(members) > 1 {
+			cycle++
+		}
+	}
+	for i := range followers {
+		if index[i] < 0 {
+			visit(i)
+		}
+	}
+	return result
+}
+
+type (
+	// CycleBreakerSorter resolves the cycles of foreign keys: the tables of the cycle are created without
+	// the foreign keys referencing each other, the keys are added by `alter table` statements at the end
+	CycleBreakerSorter struct{}
+)
+
+// Sort detaches the foreign keys between the tables of the same cycle only,
+// the statements depending on the cycle are sorted after it as usual
+func (s *CycleBreakerSorter) Sort(stmts []SqlStmt) (StatementList, error) {
+	var _, rest = topologicalOrder(stmts)
+	if len(rest) == 0 {
+		return TopologicalSort(stmts)
+	}
+	var (
+		cycle     = cycles(dependencyGraph(stmts))
+		tables    = make(map[int]map[NamedObject]bool)
+		remaining = make([]SqlStmt, 0, len(stmts))
+		deferred  = make(StatementList, 0)
+		detached  = make(map[int]*CreateStmt)
+		moved     = make(map[int]bool)
+	)
+	for i, stmt := range stmts {
+		if create, ok := stmt.(*CreateStmt); ok && create.Target == TargetTable && cycle[i] >= 0 {
+			var s, o = resolveObjectName(create.Target, create.Name)
+			if tables[cycle[i]] == nil {
+				tables[cycle[i]] = make(map[NamedObject]bool)
+			}
+			tables[cycle[i]][NamedObject{Schema: s, Object: o}] = true
+		}
+	}
+	for i, stmt := range stmts {
+		if cycle[i] < 0 {
+			continue
+		}
+		switch stmt := stmt.(type) {
+		case *CreateStmt:
+			if _, ok := stmt.Create.(*TableBodyDescriber); ok && stmt.Target == TargetTable {
+				var clone = cloneNode(stmt).(*CreateStmt)
+				deferred = append(deferred, detachForeignKeys(clone, tables[cycle[i]])...)
+				detached[i] = clone
+			}
+		case *AlterStmt:
+			if add, ok := stmt.Alter.(*AddExpr); ok && foreignKeyOf(add.Definition) != nil {
+				deferred = append(deferred, stmt)
+				moved[i] = true
+			}
+		}
+	}
+	for i, stmt := range stmts {
+		if clone, ok := detached[i]; ok {
+			stmt = clone
+		}
+		if !moved[i] {
+			remaining = append(remaining, stmt)
+		}
+	}
+	sorted, err := TopologicalSort(remaining)
+	if err != nil {
+		return nil, err
+	}
+	return append(sorted, deferred...), nil
+}
+
+// detachForeignKeys removes the foreign keys referencing the tables from the table definition
+// and returns `alter table add constraint` statements for them. Unnamed keys get the default names: table_column_fkey
+func detachForeignKeys(create *CreateStmt, tables map[NamedObject]bool) StatementList {
+	var (
+		body   = create.Create.(*TableBodyDescriber)
+		s, o   = resolveObjectName(create.Target, create.Name)
+		result = make(StatementList, 0)
+		detach = func(constraint ConstraintInterface, columns []string) bool {
+			var (
+				fk   = foreignKeyOf(constraint)
+				name string
+			)
+			if fk == nil || !tables[objectDependencies(fk.ToTable)[0]] || isObject(fk.ToTable, s, o) {
+				return false
+			}
+			if named, ok := constraint.(*NamedConstraintExpr); ok {
+				name = named.Name.GetName()
+			} else {
+				name = o + "_" + strings.Join(columns, "_") + "_fkey"
+			}
+			fk.InColumn = false
+			result = append(result, &AlterStmt{
+				Target: TargetTable,
+				Name:   create.Name,
+				Alter: &AddExpr{
+					Target: TargetConstraint,
+					Name:   &Literal{Text: name},
+					Definition: &ConstraintWithColumns{
+						Columns:    columns,
+						Constraint: &UnnamedConstraintExpr{Constraint: fk},
+					},
+				},
+			})
+			return true
+		}
+	)
+	var constraints = make([]ConstraintExpr, 0, len(body.Constraints))
+	for _, constraint := range body.Constraints {
+		if withColumns := constraintColumns(constraint); withColumns == nil || !detach(constraint, withColumns.Columns) {
+			constraints = append(constraints, constraint)
+		}
+	}
+	body.Constraints = constraints
+	for _, field := range body.Fields {
+		var constraints = make([]ConstraintExpr, 0, len(field.Constraints))
+		for _, constraint := range field.Constraints {
+			if !detach(constraint, []string{field.Name.GetName()}) {
+				constraints = append(constraints, constraint)
+			}
+		}
+		field.Constraints = constraints
+	}
+	return result
+}
+
+// foreignKeyOf returns the foreign key wrapped into the constraint, nil if it is not a foreign key
+func foreignKeyOf(node interface{}) *ConstraintForeignKeyExpr {
+	for {
+		switch c := node.(type) {
+		case *NamedConstraintExpr:
+			node = c.Constraint
+		case *UnnamedConstraintExpr:
+			node = c.Constraint
+		case *ConstraintWithColumns:
+			node = c.Constraint
+		case *ConstraintForeignKeyExpr:
+			return c
+		default:
+			return nil
+		}
+	}
+}
+
+func constraintColumns(constraint ConstraintInterface) *ConstraintWithColumns {
+	if named, ok := constraint.(*NamedConstraintExpr); ok {
+		constraint = named.Constraint
+	}
+	withColumns, _ := constraint.(*ConstraintWithColumns)
+	return withColumns
+}
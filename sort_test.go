@@ -0,0 +1,73 @@
+package sql_ast
+
+import (
+	"testing"
+)
+
+func referencingTable(name string, refs ...string) SqlStmt {
+	var body = &TableBodyDescriber{}
+	body.AddField(&SqlField{Name: &Literal{Text: "id"}, Describer: &DataTypeExpr{DataType: "int"}})
+	for _, ref := range refs {
+		body.AddField(&SqlField{
+			Name:      &Literal{Text: ref + "_id"},
+			Describer: &DataTypeExpr{DataType: "int"},
+			Constraints: []ConstraintExpr{&UnnamedConstraintExpr{Constraint: &ConstraintForeignKeyExpr{
+				ConstraintCommon: ConstraintCommon{InColumn: true},
+				ToTable:          &Selector{Container: "s", Name: ref},
+				ToColumn:         "id",
+				OnDelete:         -1,
+				OnUpdate:         -1,
+			}}},
+		})
+	}
+	return &CreateStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: name}, Create: body}
+}
+
+func TestCycleBreakerSorter_Sort(t *testing.T) {
+	var tests = []struct {
+		name     string
+		stmts    []SqlStmt
+		expected string
+	}{
+		{
+			name:     "no cycle",
+			stmts:    []SqlStmt{referencingTable("b", "a"), referencingTable("a")},
+			expected: "create table s.a (\n\tid int\n);\ncreate table s.b (\n\tid int,\n\ta_id int references s.a (id)\n);",
+		},
+		{
+			name:  "table depending on the cycle keeps its key",
+			stmts: []SqlStmt{referencingTable("c", "a"), referencingTable("a", "b"), referencingTable("b", "a")},
+			expected: "create table s.a (\n\tid int,\n\tb_id int\n);\n" +
+				"create table s.c (\n\tid int,\n\ta_id int references s.a (id)\n);\n" +
+				"create table s.b (\n\tid int,\n\ta_id int\n);\n" +
+				"alter table s.a add constraint a_b_id_fkey foreign key (b_id) references s.b (id);\n" +
+				"alter table s.b add constraint b_a_id_fkey foreign key (a_id) references s.a (id);",
+		},
+		{
+			name: "two cycles",
+			stmts: []SqlStmt{
+				referencingTable("a", "b"), referencingTable("b", "a"),
+				referencingTable("c", "d", "a"), referencingTable("d", "c"),
+			},
+			expected: "create table s.a (\n\tid int,\n\tb_id int\n);\n" +
+				"create table s.b (\n\tid int,\n\ta_id int\n);\n" +
+				"create table s.c (\n\tid int,\n\td_id int,\n\ta_id int references s.a (id)\n);\n" +
+				"create table s.d (\n\tid int,\n\tc_id int\n);\n" +
+				"alter table s.a add constraint a_b_id_fkey foreign key (b_id) references s.b (id);\n" +
+				"alter table s.b add constraint b_a_id_fkey foreign key (a_id) references s.a (id);\n" +
+				"alter table s.c add constraint c_d_id_fkey foreign key (d_id) references s.d (id);\n" +
+				"alter table s.d add constraint d_c_id_fkey foreign key (c_id) references s.c (id);",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sorted, err := (&CycleBreakerSorter{}).Sort(test.stmts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if actual := sorted.String(); actual != test.expected {
+				t.Errorf("Sort() =\n%s\nexpected\n%s", actual, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+// Package sqlasttest provides the helpers for testing the code building the statements
+package sqlasttest
+
+import (
+	sql_ast "github.com/iv-menshenin/sql-ast"
+	"testing"
+)
+
+// Equal compares the statements structurally and fails the test with the list of differing fields
+func Equal(t testing.TB, expected, actual sql_ast.SqlStmt) {
+	t.Helper()
+	if diff := sql_ast.StructuralDiff(expected, actual); diff != "" {
+		t.Errorf("statements mismatch (-expected +actual):\n%s", diff)
+	}
+}
@@ -1,152 +1,343 @@
 package sql_ast
 
 import (
+	"errors"
 	"fmt"
 	"github.com/iv-menshenin/dragonfly/utils"
+	"io"
+	"sort"
 	"strings"
 )
 
 type (
-	AlterStmt struct {
+	StatementList []SqlStmt
+	AlterStmt     struct {
 		Target SqlTarget
 		Name   SqlIdent
 		Alter  SqlExpr
 	}
+	// CreateStmt renders `create target [if not exists] name create`. There is no `create constraint` statement,
+	// for TargetConstraint the Create expression renders the whole definition (e.g. `constraint trigger ...`)
+	// and the Name identifies the created object only, it is not rendered
 	CreateStmt struct {
-		Target SqlTarget
-		Name   SqlIdent
-		Create SqlExpr
-		IfNotX bool
+		Target     SqlTarget
+		Name       SqlIdent
+		Create     SqlExpr
+		IfNotX     bool
+		Tablespace SqlIdent
 	}
 	DropStmt struct {
-		Target SqlTarget
-		Name   SqlIdent
+		Target   SqlTarget
+		Name     SqlIdent
+		IfExists bool
 	}
 	OnConflict struct {
 		Cause SqlExpr
 		Set   []SqlExpr
 	}
 	InsertStmt struct {
-		Table      TableDesc
-		Insert     map[string]SqlExpr
-		OnConflict *OnConflict
+		Table       TableDesc
+		Columns     []string
+		Values      [][]SqlExpr
+		SelectQuery SqlStmt
+		OnConflict  *OnConflict
 	}
+	// UpdateStmt joins are the part of the FROM clause, they follow the last FROM item: `from a, b join c on ...`
 	UpdateStmt struct {
 		Table TableDesc
 		Set   []SqlExpr
+		From  []TableDesc
+		Joins []JoinClause
 		Where SqlExpr
 	}
 	SelectStmt struct {
 		Columns []SqlExpr
 		From    TableDesc
+		Joins   []JoinClause
 		Where   SqlExpr
+		Windows []WindowDef
+	}
+	// WindowDef is the named window `name as (spec)` of the WINDOW clause
+	WindowDef struct {
+		Name string
+		Spec WindowSpec
+	}
+	// JoinClause kind is one of: inner, left, right, full, cross, natural [inner|left|right|full].
+	// Cross and natural joins have no condition, the others require either On or Using
+	JoinClause struct {
+		Kind  string
+		Table TableDesc
+		On    SqlExpr
+		Using []string
+	}
+	CommonTableExpr struct {
+		Name  string
+		Query SelectStmt
 	}
 	WithStmt struct {
-		Name   string
-		With   SelectStmt
+		CTEs   []CommonTableExpr
 		Select SelectStmt
 	}
 )
 
+func (c StatementList) String() string {
+	var b strings.Builder
+	_, _ = c.WriteTo(&b)
+	return b.String()
+}
+
+// WriteTo writes the statements one by one, the whole script is not kept in memory
+func (c StatementList) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for i, stmt := range c {
+		var text = stmt.String() + ";"
+		if i > 0 {
+			text = "\n" + text
+		}
+		n, err := io.WriteString(w, text)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
 func (c *AlterStmt) String() string {
-	return fmt.Sprintf("alter %s %s %s", c.Target, c.Name.GetName(), c.Alter.String())
+	return fmt.Sprintf("alter %s %s %s", c.Target, fullName(c.Name), c.Alter.String())
 }
 
 func (c *AlterStmt) statement() int { return 0 }
 
 func (c *AlterStmt) dependedOn() Dependencies {
+	var obj = c.alteredObject()
+	switch alter := c.Alter.(type) {
+	case *AddExpr, *AddColumnExpr, *AddValueExpr, *RowLevelSecurityExpr, *WithoutClusterExpr:
+		// the altered object itself is required
+		return concatDependencies(Dependencies{obj}, c.Alter.dependedOn())
+	case *SetExpr:
+		// the object is moved from the old schema to the new one, both of them are required
+		if _, ok := alter.Set.(*SchemaExpr); ok {
+			return concatDependencies(Dependencies{obj}, c.Alter.dependedOn())
+		}
+	case *SqlRename:
+		// the constraint is registered at the field level of the table the same way as by `add constraint`
+		if alter.Target == TargetConstraint {
+			return dependedOn3(obj.Schema, obj.Object, alter.OldName.GetName())
+		}
+	case *ClusterOnExpr:
+		// the index is in the schema of the table
+		var index = objectDependencies(alter.IndexName)[0]
+		if index.Schema == "" {
+			index.Schema = obj.Schema
+		}
+		return Dependencies{obj, index}
+	}
 	return c.Alter.dependedOn()
 }
 
+// alteredObject refers to the altered object, the unqualified name stays without the schema
+func (c *AlterStmt) alteredObject() NamedObject {
+	if c.Target == TargetSchema {
+		return NamedObject{Schema: c.Name.GetName()}
+	}
+	return objectDependencies(c.Name)[0]
+}
+
 func (c *AlterStmt) solved() Dependencies {
-	var s, o, f string
-	if name, ok := c.Name.(*Selector); ok {
-		s, o = name.Container, name.Name
-	} else if n := strings.Split(c.Name.GetName(), "."); len(n) > 1 {
-		s, o = n[0], n[1]
-	} else {
-		if c.Target == TargetSchema {
-			s = c.Name.GetName()
-		} else {
-			panic("cannot resolve schema for `" + c.Name.GetName() + "`")
+	var (
+		s, o = resolveObjectName(c.Target, c.Name)
+		f    string
+	)
+	switch alter := c.Alter.(type) {
+	case *AddExpr:
+		f = alter.Name.GetName()
+	case *AddColumnExpr:
+		f = alter.Column.Name.GetName()
+	case *SetExpr:
+		// the object is moved to another schema, the new schema is required by dependedOn
+		if schema, ok := alter.Set.(*SchemaExpr); ok {
+			s = schema.SchemaName
+		}
+	case *SqlRename:
+		// the object is known by the new name now
+		switch {
+		case alter.Target == TargetNone && c.Target == TargetSchema:
+			s = alter.NewName.GetName()
+		case alter.Target == TargetNone:
+			o = alter.NewName.GetName()
+		case alter.Target == TargetColumn, alter.Target == TargetConstraint:
+			f = alter.NewName.GetName()
 		}
-	}
-	if add, ok := c.Alter.(*AddExpr); ok {
-		f = add.Name.GetName()
 	}
 	return dependedOn3(s, o, f)
 }
 
+// unsolved is the old name of the renamed object and the dropped column or constraint.
+// The name of the altered object may be unqualified, the same as the references to the objects
+func (c *AlterStmt) unsolved() Dependencies {
+	var field string
+	switch alter := c.Alter.(type) {
+	case *DropExpr:
+		field = alter.Name.GetName()
+	case *DropColumnExpr:
+		field = alter.Column.GetName()
+	case *SqlRename:
+		switch alter.Target {
+		case TargetNone:
+		case TargetColumn, TargetConstraint:
+			field = alter.OldName.GetName()
+		default:
+			return nil
+		}
+	default:
+		return nil
+	}
+	var obj = c.alteredObject()
+	return dependedOn3(obj.Schema, obj.Object, field)
+}
+
+// resolveObjectName splits the name of the object into schema and object name, the schema is required
+func resolveObjectName(target SqlTarget, name SqlIdent) (s, o string) {
+	if selector, ok := name.(*Selector); ok {
+		return selector.Container, selector.Name
+	} else if n := strings.Split(name.GetName(), "."); len(n) > 1 {
+		return n[0], n[1]
+	}
+	if target == TargetSchema {
+		return name.GetName(), ""
+	}
+	if target == TargetTablespace {
+		return "", name.GetName()
+	}
+	panic("cannot resolve schema for `" + fullName(name) + "`")
+}
+
 func (c *CreateStmt) String() string {
 	ifNotExists := ""
 	if c.IfNotX {
 		ifNotExists = "if not exists"
 	}
 	if c.Target == TargetConstraint {
+		// the name is a part of the Create expression, see CreateStmt
 		return utils.NonEmptyStringsConcatSpaceSeparated("create", c.Create)
 	}
-	return utils.NonEmptyStringsConcatSpaceSeparated("create", c.Target, ifNotExists, c.Name.GetName(), c.Create)
+	var tablespace string
+	if c.Tablespace != nil {
+		if body, ok := c.Create.(*TableBodyDescriber); ok && body.Tablespace != nil {
+			panic("CreateStmt allows just Tablespace or TableBodyDescriber.Tablespace not both")
+		}
+		tablespace = "tablespace " + c.Tablespace.GetName()
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated("create", c.Target, ifNotExists, fullName(c.Name), c.Create, tablespace)
 }
 
 func (c *CreateStmt) statement() int { return 0 }
 
 func (c *CreateStmt) dependedOn() Dependencies {
 	if c.Create != nil {
-		return c.Create.dependedOn()
+		return concatDependencies(c.Create.dependedOn(), tablespaceDependencies(c.Tablespace))
 	}
-	return nil
+	return tablespaceDependencies(c.Tablespace)
 }
 
 func (c *CreateStmt) solved() (result Dependencies) {
-	var s, o string
-	if name, ok := c.Name.(*Selector); ok {
-		s, o = name.Container, name.Name
-	} else if n := strings.Split(c.Name.GetName(), "."); len(n) > 1 {
-		s, o = n[0], n[1]
-	} else {
-		if c.Target == TargetSchema {
-			s = c.Name.GetName()
-		} else {
-			panic("cannot resolve schema for `" + c.Name.GetName() + "`")
-		}
-	}
+	var s, o = resolveObjectName(c.Target, c.Name)
 	result = dependedOn2(s, o)
 	if c.Create != nil {
 		if body, ok := c.Create.(*TableBodyDescriber); ok {
 			for _, f := range body.Fields {
 				result = concatDependencies(result, dependedOn3(s, o, f.Name.GetName()))
 			}
+			for _, constraint := range body.Constraints {
+				if named, ok := constraint.(*NamedConstraintExpr); ok {
+					result = concatDependencies(result, dependedOn3(s, o, named.Name.GetName()))
+				}
+			}
 		}
 	}
 	return result
 }
 
+func (c *CreateStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
 func (c *DropStmt) String() string {
-	return utils.NonEmptyStringsConcatSpaceSeparated("drop", c.Target, c.Name.GetName())
+	var ifExistsExpr string
+	if c.IfExists {
+		ifExistsExpr = "if exists"
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated("drop", c.Target, ifExistsExpr, fullName(c.Name))
 }
 
 func (c *DropStmt) statement() int { return 0 }
 
+// dependedOn of the drop is the object itself, it has to be created before it can be dropped.
+// The drop with IfExists does not require the object
 func (c *DropStmt) dependedOn() Dependencies {
-	return nil
+	if c.IfExists {
+		return nil
+	}
+	return c.droppedObject()
+}
+
+func (c *DropStmt) droppedObject() Dependencies {
+	if c.Target == TargetSchema {
+		return dependedOn2(c.Name.GetName(), "")
+	}
+	return objectDependencies(c.Name)
 }
 
+// solved is empty, the drop brings nothing into existence
 func (c *DropStmt) solved() (result Dependencies) {
 	return nil
 }
 
+// unsolved is the dropped object, nothing can depend on it after the drop
+func (c *DropStmt) unsolved() Dependencies {
+	return c.droppedObject()
+}
+
 func (c *UpdateStmt) String() string {
 	var (
 		clauseSet   = make([]string, 0, len(c.Set))
+		clauseFrom  = make([]string, 0, len(c.From))
 		clauseWhere = "1 = 1"
+		clauseTable = fullName(c.Table.Table)
 	)
 	for _, set := range c.Set {
 		clauseSet = append(clauseSet, set.String())
 	}
+	for i := range c.From {
+		clauseFrom = append(clauseFrom, c.From[i].String())
+	}
+	if len(c.Joins) > 0 && len(c.From) == 0 {
+		panic("UpdateStmt requires From to join the tables")
+	}
+	var clauseJoins = make([]string, 0, len(c.Joins))
+	for i := range c.Joins {
+		clauseJoins = append(clauseJoins, c.Joins[i].String())
+	}
 	if c.Where != nil {
 		clauseWhere = c.Where.String()
 	}
-	return fmt.Sprintf("update %s %s set %s where %s", c.Table.Table.GetName(), c.Table.Alias, strings.Join(clauseSet, ", "), clauseWhere)
+	if c.Table.Alias != "" {
+		clauseTable += " as " + c.Table.Alias
+	}
+	if len(clauseFrom) > 0 {
+		return utils.NonEmptyStringsConcatSpaceSeparated(
+			"update", clauseTable, "set", strings.Join(clauseSet, ", "),
+			"from", strings.Join(clauseFrom, ", "), strings.Join(clauseJoins, " "), "where", clauseWhere,
+		)
+	}
+	return fmt.Sprintf("update %s set %s where %s", clauseTable, strings.Join(clauseSet, ", "), clauseWhere)
+}
+
+// AddSet appends the `col = expr` expression to the SET clause
+func (c *UpdateStmt) AddSet(expr SqlExpr) *UpdateStmt {
+	c.Set = append(c.Set, expr)
+	return c
 }
 
 func (c *UpdateStmt) statement() int { return 0 }
@@ -156,13 +347,28 @@ func (c *UpdateStmt) dependedOn() Dependencies {
 	for _, s := range c.Set {
 		result = concatDependencies(result, s.dependedOn())
 	}
-	return result
+	var items = append([]TableDesc{c.Table}, c.From...)
+	for i := range c.From {
+		result = concatDependencies(result, c.From[i].dependedOn())
+	}
+	for i := range c.Joins {
+		result = concatDependencies(result, c.Joins[i].dependedOn())
+		items = append(items, c.Joins[i].Table)
+	}
+	if c.Where != nil {
+		result = concatDependencies(result, c.Where.dependedOn())
+	}
+	return resolveAliases(result, items...)
 }
 
 func (c *UpdateStmt) solved() (result Dependencies) {
 	return nil
 }
 
+func (c *UpdateStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
 func (c *OnConflict) String() string {
 	if c == nil {
 		return ""
@@ -181,39 +387,84 @@ func (c *OnConflict) String() string {
 }
 
 func (c *InsertStmt) String() string {
+	var columns = ""
+	if len(c.Columns) > 0 {
+		columns = "(" + strings.Join(c.Columns, ", ") + ")"
+	}
+	if c.SelectQuery != nil {
+		if len(c.Values) > 0 {
+			panic("InsertStmt allows just Values or SelectQuery not both")
+		}
+		return utils.NonEmptyStringsConcatSpaceSeparated("insert into", fullName(c.Table.Table), columns, c.SelectQuery, c.OnConflict)
+	}
+	if len(c.Values) == 0 {
+		panic("InsertStmt requires Values or SelectQuery")
+	}
 	var (
-		fieldsList = make([]string, 0)
-		valuesList = make([]string, 0)
+		rowsList = make([]string, 0, len(c.Values))
+		width    = len(c.Values[0])
 	)
-	for f, s := range c.Insert {
-		fieldsList = append(fieldsList, fmt.Sprintf("%s", f))
-		valuesList = append(valuesList, fmt.Sprintf("%s", s))
+	if len(c.Columns) > 0 {
+		width = len(c.Columns)
 	}
-	return fmt.Sprintf(
-		"insert into %s (%s) values (%s) %s",
-		c.Table.Table.GetName(),
-		strings.Join(fieldsList, ", "),
-		strings.Join(valuesList, ", "),
-		c.OnConflict,
+	for i, row := range c.Values {
+		if len(row) != width {
+			panic(fmt.Sprintf("InsertStmt row %d has %d values, expected %d", i, len(row), width))
+		}
+		var valuesList = make([]string, 0, len(row))
+		for _, s := range row {
+			valuesList = append(valuesList, s.String())
+		}
+		rowsList = append(rowsList, "("+strings.Join(valuesList, ", ")+")")
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated(
+		"insert into", fullName(c.Table.Table), columns, "values", strings.Join(rowsList, ", "), c.OnConflict,
 	)
 }
 
+// AddRow appends a row of values, they must follow the order of Columns
+func (c *InsertStmt) AddRow(values ...SqlExpr) *InsertStmt {
+	c.Values = append(c.Values, values)
+	return c
+}
+
+// AddColumn appends the column and its value to each row, the first row is made if there are no rows.
+// Each row gets its own copy of the value, so that the rows can be rewritten separately
+func (c *InsertStmt) AddColumn(name string, value SqlExpr) *InsertStmt {
+	c.Columns = append(c.Columns, name)
+	if len(c.Values) == 0 {
+		c.Values = append(c.Values, nil)
+	}
+	for i := range c.Values {
+		if i > 0 {
+			value = cloneNode(value).(SqlExpr)
+		}
+		c.Values[i] = append(c.Values[i], value)
+	}
+	return c
+}
+
 func (c *InsertStmt) statement() int { return 0 }
 
 func (c *InsertStmt) dependedOn() Dependencies {
-	return []NamedObject{
-		{
-			Schema: "", // TODO ?
-			Object: c.Table.Table.GetName(),
-			Field:  "",
-		},
+	var result = objectDependencies(c.Table.Table)
+	for _, row := range c.Values {
+		result = concatDependencies(result, expressionsDependencies(row))
 	}
+	if c.SelectQuery != nil {
+		result = concatDependencies(result, c.SelectQuery.dependedOn())
+	}
+	return result
 }
 
 func (c *InsertStmt) solved() (result Dependencies) {
 	return nil
 }
 
+func (c *InsertStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
 func (c *SelectStmt) String() string {
 	var (
 		clauseColumns = make([]string, 0, len(c.Columns))
@@ -225,29 +476,907 @@ func (c *SelectStmt) String() string {
 	if c.Where != nil {
 		clauseWhere = c.Where.String()
 	}
-	return fmt.Sprintf("select %s from %s %s where %s", strings.Join(clauseColumns, ", "), c.From.Table.GetName(), c.From.Alias, clauseWhere)
+	var clauseFrom string
+	if c.From.Table != nil || c.From.Query != nil {
+		var items = []string{"from", c.From.String()}
+		for i := range c.Joins {
+			items = append(items, c.Joins[i].String())
+		}
+		clauseFrom = strings.Join(items, " ")
+	}
+	var clauseWindow string
+	if len(c.Windows) > 0 {
+		var windows = make([]string, 0, len(c.Windows))
+		for i := range c.Windows {
+			windows = append(windows, c.Windows[i].String())
+		}
+		clauseWindow = "window " + strings.Join(windows, ", ")
+	}
+	if clauseFrom == "" && c.Where == nil {
+		// valueless select, e.g. `select now()`
+		return utils.NonEmptyStringsConcatSpaceSeparated("select", strings.Join(clauseColumns, ", "), clauseWindow)
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated("select", strings.Join(clauseColumns, ", "), clauseFrom, "where", clauseWhere, clauseWindow)
+}
+
+func (c *WindowDef) String() string {
+	return fmt.Sprintf("%s as (%s)", c.Name, c.Spec.String())
+}
+
+func (c *SelectStmt) AddColumn(expr SqlExpr) *SelectStmt {
+	c.Columns = append(c.Columns, expr)
+	return c
+}
+
+func (c *SelectStmt) AddColumns(exprs ...SqlExpr) *SelectStmt {
+	c.Columns = append(c.Columns, exprs...)
+	return c
+}
+
+// FilterColumns returns the columns matching the predicate, nested queries are not searched
+func (c *SelectStmt) FilterColumns(predicate func(SqlExpr) bool) []SqlExpr {
+	var result = make([]SqlExpr, 0)
+	for _, col := range c.Columns {
+		if predicate(col) {
+			result = append(result, col)
+		}
+	}
+	return result
+}
+
+// MapColumns returns a shallow copy of the statement with the columns replaced by the results of fn
+func (c *SelectStmt) MapColumns(fn func(SqlExpr) SqlExpr) *SelectStmt {
+	var result = *c
+	result.Columns = make([]SqlExpr, 0, len(c.Columns))
+	for _, col := range c.Columns {
+		result.Columns = append(result.Columns, fn(col))
+	}
+	return &result
 }
 
 func (c *SelectStmt) statement() int { return 0 }
 
 func (c *SelectStmt) dependedOn() Dependencies {
-	return nil
+	var (
+		result = c.From.dependedOn()
+		items  = []TableDesc{c.From}
+	)
+	for i := range c.Joins {
+		result = concatDependencies(result, c.Joins[i].dependedOn())
+		items = append(items, c.Joins[i].Table)
+	}
+	for _, col := range c.Columns {
+		result = concatDependencies(result, col.dependedOn())
+	}
+	if c.Where != nil {
+		result = concatDependencies(result, c.Where.dependedOn())
+	}
+	for i := range c.Windows {
+		result = concatDependencies(result, c.Windows[i].Spec.dependedOn())
+	}
+	return resolveAliases(result, items...)
+}
+
+// resolveAliases binds the columns qualified with the names of the FROM items to the tables. The columns
+// of the aliased subqueries are not objects, they are skipped. Nested statements resolve their own aliases first,
+// so the remaining references of the correlated subqueries are resolved by the enclosing statement
+func resolveAliases(deps Dependencies, items ...TableDesc) Dependencies {
+	var (
+		scope  = make(map[string]*NamedObject, len(items))
+		result = make(Dependencies, 0, len(deps))
+	)
+	for i := range items {
+		var table *NamedObject
+		if items[i].Table != nil {
+			table = &objectDependencies(items[i].Table)[0]
+		}
+		switch {
+		case items[i].Alias != "":
+			scope[items[i].Alias] = table
+		case table != nil && table.Schema != "":
+			// the table of the schema can be referred without the schema
+			scope[table.Object] = table
+		}
+	}
+	for _, dep := range deps {
+		table, ok := scope[dep.Object]
+		switch {
+		case !ok || dep.Schema != "" || dep.Field == "":
+			result = append(result, dep)
+		case table != nil:
+			result = append(result, NamedObject{Schema: table.Schema, Object: table.Object, Field: dep.Field})
+		}
+	}
+	return result
 }
 
 func (c *SelectStmt) solved() (result Dependencies) {
 	return nil
 }
 
+func (c *SelectStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
+func (c *JoinClause) Validate() error {
+	var (
+		kind      = strings.ToLower(c.Kind)
+		natural   = strings.TrimSpace(strings.TrimPrefix(kind, "natural"))
+		condition = c.On != nil || len(c.Using) > 0
+	)
+	switch {
+	case c.On != nil && len(c.Using) > 0:
+		return errors.New("join allows just On or Using not both")
+	case kind == "cross" || (natural != kind && (natural == "" || utils.ArrayContainsCI(joinKinds, natural))):
+		if condition {
+			return fmt.Errorf("%s join cannot have a condition", kind)
+		}
+	case !utils.ArrayContainsCI(joinKinds, kind):
+		return fmt.Errorf("unknown join kind `%s`", c.Kind)
+	case !condition:
+		return fmt.Errorf("%s join requires a condition", kind)
+	}
+	return nil
+}
+
+var joinKinds = []string{"inner", "left", "right", "full"}
+
+func (c *JoinClause) String() string {
+	if err := c.Validate(); err != nil {
+		panic(err)
+	}
+	var condition string
+	if c.On != nil {
+		condition = "on " + c.On.String()
+	}
+	if len(c.Using) > 0 {
+		condition = "using (" + strings.Join(c.Using, ", ") + ")"
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated(strings.ToLower(c.Kind), "join", c.Table.String(), condition)
+}
+
+func (c *JoinClause) dependedOn() Dependencies {
+	var result = c.Table.dependedOn()
+	if c.On != nil {
+		result = concatDependencies(result, c.On.dependedOn())
+	}
+	return result
+}
+
+func (c *CommonTableExpr) String() string {
+	return fmt.Sprintf("%s as (%s)", c.Name, c.Query.String())
+}
+
+// String calls the methods on the fields explicitly, the queries are stored by value
+// and fmt would not find the pointer receiver methods of the copies
 func (c *WithStmt) String() string {
-	return fmt.Sprintf("with %s as (%s) %s", c.Name, c.With, c.Select)
+	var ctes = make([]string, 0, len(c.CTEs))
+	for i := range c.CTEs {
+		ctes = append(ctes, c.CTEs[i].String())
+	}
+	return fmt.Sprintf("with %s %s", strings.Join(ctes, ", "), c.Select.String())
+}
+
+// CTE returns the query of the common table expression, it can be modified in place
+func (c *WithStmt) CTE(name string) (*SelectStmt, bool) {
+	for i := range c.CTEs {
+		if c.CTEs[i].Name == name {
+			return &c.CTEs[i].Query, true
+		}
+	}
+	return nil, false
+}
+
+// SetCTE replaces the query of the existing common table expression
+func (c *WithStmt) SetCTE(name string, query SelectStmt) bool {
+	if cte, ok := c.CTE(name); ok {
+		*cte = query
+		return true
+	}
+	return false
 }
 
 func (c *WithStmt) statement() int { return 0 }
 
 func (c *WithStmt) dependedOn() Dependencies {
-	return append(c.Select.dependedOn(), c.With.dependedOn()...)
+	var (
+		result = make(Dependencies, 0)
+		all    = c.Select.dependedOn()
+		names  = make(map[string]bool, len(c.CTEs))
+	)
+	for i := range c.CTEs {
+		names[c.CTEs[i].Name] = true
+		all = concatDependencies(all, c.CTEs[i].Query.dependedOn())
+	}
+	// the references to CTEs are resolved inside the statement
+	for _, dep := range all {
+		if dep.Schema == "" && names[dep.Object] {
+			continue
+		}
+		result = append(result, dep)
+	}
+	return result
 }
 
 func (c *WithStmt) solved() (result Dependencies) {
-	return append(c.Select.solved(), c.With.solved()...)
+	result = c.Select.solved()
+	for i := range c.CTEs {
+		result = concatDependencies(result, c.CTEs[i].Query.solved())
+	}
+	return result
+}
+
+func (c *WithStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
+type (
+	CreateRuleStmt struct {
+		Name    SqlIdent
+		Event   string
+		Table   SqlIdent
+		Instead bool
+		Where   SqlExpr
+		Actions []SqlStmt
+	}
+)
+
+func (c *CreateRuleStmt) String() string {
+	var (
+		clauseWhere   = ""
+		clauseInstead = "also"
+		clauseActions = "nothing"
+	)
+	if c.Where != nil {
+		clauseWhere = "where " + c.Where.String()
+	}
+	if c.Instead {
+		clauseInstead = "instead"
+	}
+	if len(c.Actions) == 1 {
+		clauseActions = c.Actions[0].String()
+	} else if len(c.Actions) > 1 {
+		clauseActions = "(" + strings.TrimSuffix(StatementList(c.Actions).String(), ";") + ")"
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated(
+		"create rule", c.Name.GetName(), "as on", strings.ToLower(c.Event), "to", fullName(c.Table),
+		clauseWhere, "do", clauseInstead, clauseActions,
+	)
+}
+
+func (c *CreateRuleStmt) statement() int { return 0 }
+
+func (c *CreateRuleStmt) dependedOn() Dependencies {
+	var result = objectDependencies(c.Table)
+	if c.Where != nil {
+		result = concatDependencies(result, c.Where.dependedOn())
+	}
+	for _, action := range c.Actions {
+		result = concatDependencies(result, action.dependedOn())
+	}
+	return result
+}
+
+func (c *CreateRuleStmt) solved() (result Dependencies) {
+	return nil
+}
+
+func (c *CreateRuleStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
+type (
+	CreatePolicyStmt struct {
+		Name        SqlIdent
+		Table       SqlIdent
+		Restrictive bool
+		Command     string
+		Roles       []string
+		Using       SqlExpr
+		WithCheck   SqlExpr
+	}
+	AlterPolicyStmt struct {
+		Name      SqlIdent
+		Table     SqlIdent
+		NewName   SqlIdent
+		Roles     []string
+		Using     SqlExpr
+		WithCheck SqlExpr
+	}
+	DropPolicyStmt struct {
+		Name              SqlIdent
+		Table             SqlIdent
+		IfExists, Cascade bool
+	}
+)
+
+func policyClauses(roles []string, using, withCheck SqlExpr) string {
+	var clauseRoles, clauseUsing, clauseCheck string
+	if len(roles) > 0 {
+		clauseRoles = "to " + strings.Join(roles, ", ")
+	}
+	if using != nil {
+		clauseUsing = fmt.Sprintf("using (%s)", using)
+	}
+	if withCheck != nil {
+		clauseCheck = fmt.Sprintf("with check (%s)", withCheck)
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated(clauseRoles, clauseUsing, clauseCheck)
+}
+
+func policyDependencies(table SqlIdent, using, withCheck SqlExpr) Dependencies {
+	var result = objectDependencies(table)
+	if using != nil {
+		result = concatDependencies(result, using.dependedOn())
+	}
+	if withCheck != nil {
+		result = concatDependencies(result, withCheck.dependedOn())
+	}
+	return result
+}
+
+func (c *CreatePolicyStmt) String() string {
+	var clauseAs, clauseFor string
+	if c.Restrictive {
+		clauseAs = "as restrictive"
+	}
+	if c.Command != "" {
+		clauseFor = "for " + strings.ToLower(c.Command)
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated(
+		"create policy", c.Name.GetName(), "on", fullName(c.Table), clauseAs, clauseFor,
+		policyClauses(c.Roles, c.Using, c.WithCheck),
+	)
+}
+
+func (c *CreatePolicyStmt) statement() int { return 0 }
+
+func (c *CreatePolicyStmt) dependedOn() Dependencies {
+	return policyDependencies(c.Table, c.Using, c.WithCheck)
+}
+
+func (c *CreatePolicyStmt) solved() (result Dependencies) {
+	return nil
+}
+
+func (c *CreatePolicyStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
+func (c *AlterPolicyStmt) String() string {
+	if c.NewName != nil {
+		return utils.NonEmptyStringsConcatSpaceSeparated("alter policy", c.Name.GetName(), "on", fullName(c.Table), "rename to", c.NewName.GetName())
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated(
+		"alter policy", c.Name.GetName(), "on", fullName(c.Table), policyClauses(c.Roles, c.Using, c.WithCheck),
+	)
+}
+
+func (c *AlterPolicyStmt) statement() int { return 0 }
+
+func (c *AlterPolicyStmt) dependedOn() Dependencies {
+	return policyDependencies(c.Table, c.Using, c.WithCheck)
+}
+
+func (c *AlterPolicyStmt) solved() (result Dependencies) {
+	return nil
+}
+
+func (c *AlterPolicyStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
+func (c *DropPolicyStmt) String() string {
+	cascadeExpr, ifExistsExpr := "", ""
+	if c.IfExists {
+		ifExistsExpr = "if exists"
+	}
+	if c.Cascade {
+		cascadeExpr = "cascade"
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated("drop policy", ifExistsExpr, c.Name.GetName(), "on", fullName(c.Table), cascadeExpr)
+}
+
+func (c *DropPolicyStmt) statement() int { return 0 }
+
+func (c *DropPolicyStmt) dependedOn() Dependencies {
+	return objectDependencies(c.Table)
+}
+
+func (c *DropPolicyStmt) solved() (result Dependencies) {
+	return nil
+}
+
+func (c *DropPolicyStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
+type (
+	// NopStmt is an empty statement, it keeps the comments between the statements
+	NopStmt struct {
+		Comment string
+	}
+)
+
+func (c *NopStmt) String() string {
+	if c.Comment == "" {
+		return ""
+	}
+	return "/* " + c.Comment + " */"
+}
+
+func (c *NopStmt) statement() int { return 0 }
+
+func (c *NopStmt) dependedOn() Dependencies {
+	return nil
+}
+
+func (c *NopStmt) solved() (result Dependencies) {
+	return nil
+}
+
+func (c *NopStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
+type (
+	// RawStmt is the SQL that is not modeled by the package, it is emitted as is and has no dependencies
+	RawStmt struct {
+		SQL string
+	}
+)
+
+func (c *RawStmt) String() string {
+	return c.SQL
+}
+
+func (c *RawStmt) statement() int { return 0 }
+
+func (c *RawStmt) dependedOn() Dependencies {
+	return nil
+}
+
+func (c *RawStmt) solved() (result Dependencies) {
+	return nil
+}
+
+func (c *RawStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
+type (
+	CreateIndexStmt struct {
+		Name    SqlIdent
+		Table   SqlIdent
+		Unique  bool
+		IfNotX  bool
+		Using   string
+		Keys    []IndexKey
+		Include []SqlIdent
+		Where   SqlExpr
+		// Tablespace is the tablespace of the index, it does not have to be the one of the table
+		Tablespace SqlIdent
+		// Concurrently builds the index without locking writes, it cannot be used inside a transaction
+		Concurrently bool
+	}
+	IndexKey interface {
+		String() string
+		keyDependencies(table NamedObject) Dependencies
+	}
+	// ColumnIndexKey is `column [opclass] [asc|desc]`
+	ColumnIndexKey struct {
+		Column  SqlIdent
+		OpClass SqlIdent
+		Order   string
+	}
+	// ExprIndexKey is `(expr) [opclass] [asc|desc]`
+	ExprIndexKey struct {
+		Expr    SqlExpr
+		OpClass SqlIdent
+		Order   string
+	}
+)
+
+func (c *ColumnIndexKey) String() string {
+	return utils.NonEmptyStringsConcatSpaceSeparated(c.Column.GetName(), opClassName(c.OpClass), strings.ToLower(c.Order))
+}
+
+func (c *ColumnIndexKey) keyDependencies(table NamedObject) Dependencies {
+	return concatDependencies(dependedOn3(table.Schema, table.Object, c.Column.GetName()), opClassDependencies(c.OpClass))
+}
+
+func (c *ExprIndexKey) String() string {
+	return utils.NonEmptyStringsConcatSpaceSeparated("("+c.Expr.String()+")", opClassName(c.OpClass), strings.ToLower(c.Order))
+}
+
+func (c *ExprIndexKey) keyDependencies(table NamedObject) Dependencies {
+	return concatDependencies(indexExprDependencies(c.Expr, table), opClassDependencies(c.OpClass))
+}
+
+func opClassName(opClass SqlIdent) string {
+	if opClass == nil {
+		return ""
+	}
+	return fullName(opClass)
+}
+
+// opClassDependencies refers to the operator class created in the schema, e.g. by the extension.
+// Unqualified and pg_catalog operator classes are considered built-in
+func opClassDependencies(opClass SqlIdent) Dependencies {
+	if opClass == nil {
+		return nil
+	}
+	if obj := objectDependencies(opClass)[0]; obj.Schema != "" && obj.Schema != "pg_catalog" {
+		return Dependencies{obj}
+	}
+	return nil
+}
+
+func (c *CreateIndexStmt) String() string {
+	var clauseUnique, clauseConcurrently, ifNotExists, indexName, clauseUsing, clauseInclude, clauseTablespace, clauseWhere string
+	if c.Unique {
+		clauseUnique = "unique"
+	}
+	if c.Concurrently {
+		clauseConcurrently = "concurrently"
+	}
+	if c.IfNotX {
+		ifNotExists = "if not exists"
+	}
+	if c.Name != nil {
+		indexName = c.Name.GetName()
+	}
+	if c.Using != "" {
+		clauseUsing = "using " + strings.ToLower(c.Using)
+	}
+	if len(c.Include) > 0 {
+		clauseInclude = "include (" + joinIdents(c.Include) + ")"
+	}
+	if c.Tablespace != nil {
+		clauseTablespace = "tablespace " + c.Tablespace.GetName()
+	}
+	if c.Where != nil {
+		clauseWhere = "where " + c.Where.String()
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated(
+		"create", clauseUnique, "index", clauseConcurrently, ifNotExists, indexName, "on", fullName(c.Table), clauseUsing,
+		"("+joinIndexKeys(c.Keys)+")", clauseInclude, clauseTablespace, clauseWhere,
+	)
+}
+
+func joinIndexKeys(keys []IndexKey) string {
+	var s = make([]string, 0, len(keys))
+	for _, key := range keys {
+		s = append(s, key.String())
+	}
+	return strings.Join(s, ", ")
+}
+
+func joinIdents(idents []SqlIdent) string {
+	var s = make([]string, 0, len(idents))
+	for _, ident := range idents {
+		s = append(s, ident.GetName())
+	}
+	return strings.Join(s, ", ")
+}
+
+func (c *CreateIndexStmt) statement() int { return 0 }
+
+// dependedOn contains the table and all its columns used by the index, including the non-key ones
+func (c *CreateIndexStmt) dependedOn() Dependencies {
+	var (
+		table  = objectDependencies(c.Table)[0]
+		result = Dependencies{table}
+	)
+	for _, key := range c.Keys {
+		result = concatDependencies(result, key.keyDependencies(table))
+	}
+	for _, column := range c.Include {
+		result = concatDependencies(result, dependedOn3(table.Schema, table.Object, column.GetName()))
+	}
+	if c.Where != nil {
+		result = concatDependencies(result, indexExprDependencies(c.Where, table))
+	}
+	return concatDependencies(result, tablespaceDependencies(c.Tablespace))
+}
+
+// indexExprDependencies binds the unqualified column references of the expression to the indexed table,
+// the functions defined in the schemas are required as well
+func indexExprDependencies(expr SqlExpr, table NamedObject) Dependencies {
+	var result = expr.dependedOn()
+	Walk(expr, func(node interface{}) bool {
+		switch n := node.(type) {
+		case *ColumnRef:
+			if n.Table == nil {
+				result = concatDependencies(result, dependedOn3(table.Schema, table.Object, n.Column.GetName()))
+			}
+		case *FncCall:
+			if fn := objectDependencies(n.Name)[0]; fn.Schema != "" && fn.Schema != "pg_catalog" {
+				result = concatDependencies(result, Dependencies{fn})
+			}
+		}
+		return true
+	})
+	return result
+}
+
+// solved is the index in the schema of the table, the unnamed index gets its name from the server
+func (c *CreateIndexStmt) solved() (result Dependencies) {
+	if c.Name == nil {
+		return nil
+	}
+	return dependedOn2(objectDependencies(c.Table)[0].Schema, c.Name.GetName())
+}
+
+func (c *CreateIndexStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
+type (
+	BeginStmt  struct{}
+	CommitStmt struct{}
+)
+
+func (c *BeginStmt) String() string {
+	return "begin"
+}
+
+func (c *BeginStmt) statement() int { return 0 }
+
+func (c *BeginStmt) dependedOn() Dependencies {
+	return nil
+}
+
+func (c *BeginStmt) solved() (result Dependencies) {
+	return nil
+}
+
+func (c *BeginStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
+func (c *CommitStmt) String() string {
+	return "commit"
+}
+
+func (c *CommitStmt) statement() int { return 0 }
+
+func (c *CommitStmt) dependedOn() Dependencies {
+	return nil
+}
+
+func (c *CommitStmt) solved() (result Dependencies) {
+	return nil
+}
+
+func (c *CommitStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
+type (
+	DoStmt struct {
+		Language string
+		Body     string
+	}
+)
+
+func (c *DoStmt) String() string {
+	var language string
+	if c.Language != "" {
+		language = "language " + c.Language
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated("do", language, dollarQuote(c.Body))
+}
+
+func (c *DoStmt) statement() int { return 0 }
+
+// dependedOn is unknown, the body is not parsed
+func (c *DoStmt) dependedOn() Dependencies {
+	return nil
+}
+
+func (c *DoStmt) solved() (result Dependencies) {
+	return nil
+}
+
+func (c *DoStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
+type (
+	CreatePublicationStmt struct {
+		Name        string
+		Tables      []SqlIdent
+		AllTables   bool
+		WithOptions map[string]string
+	}
+	CreateSubscriptionStmt struct {
+		Name         string
+		ConnInfo     string
+		Publications []string
+		WithOptions  map[string]string
+	}
+)
+
+// withOptions renders `with (key = 'value', ...)` sorted by the keys
+func withOptions(options map[string]string) string {
+	if len(options) == 0 {
+		return ""
+	}
+	var keys = make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		keys[i] = key + " = " + (&String{X: options[key]}).String()
+	}
+	return "with (" + strings.Join(keys, ", ") + ")"
+}
+
+func (c *CreatePublicationStmt) String() string {
+	var clauseFor string
+	if c.AllTables {
+		clauseFor = "for all tables"
+	} else if len(c.Tables) > 0 {
+		var tables = make([]string, 0, len(c.Tables))
+		for _, table := range c.Tables {
+			tables = append(tables, fullName(table))
+		}
+		clauseFor = "for table " + strings.Join(tables, ", ")
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated("create publication", c.Name, clauseFor, withOptions(c.WithOptions))
+}
+
+func (c *CreatePublicationStmt) statement() int { return 0 }
+
+func (c *CreatePublicationStmt) dependedOn() Dependencies {
+	var result Dependencies
+	for _, table := range c.Tables {
+		result = concatDependencies(result, objectDependencies(table))
+	}
+	return result
+}
+
+func (c *CreatePublicationStmt) solved() (result Dependencies) {
+	return nil
+}
+
+func (c *CreatePublicationStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
+func (c *CreateSubscriptionStmt) String() string {
+	return utils.NonEmptyStringsConcatSpaceSeparated(
+		"create subscription", c.Name, "connection", (&String{X: c.ConnInfo}).String(),
+		"publication", strings.Join(c.Publications, ", "), withOptions(c.WithOptions),
+	)
+}
+
+func (c *CreateSubscriptionStmt) statement() int { return 0 }
+
+// dependedOn is empty, the publications are on the remote server
+func (c *CreateSubscriptionStmt) dependedOn() Dependencies {
+	return nil
+}
+
+func (c *CreateSubscriptionStmt) solved() (result Dependencies) {
+	return nil
+}
+
+func (c *CreateSubscriptionStmt) unsolved() (result Dependencies) {
+	return nil
+}
+
+type (
+	// DropFunctionStmt identifies the function by its argument types, the overloaded functions share the name
+	DropFunctionStmt struct {
+		Name              SqlIdent
+		ArgTypes          []*DataTypeExpr
+		IfExists, Cascade bool
+	}
+)
+
+func (c *DropFunctionStmt) String() string {
+	var (
+		args                      = make([]string, 0, len(c.ArgTypes))
+		cascadeExpr, ifExistsExpr string
+	)
+	for _, arg := range c.ArgTypes {
+		args = append(args, arg.String())
+	}
+	if c.IfExists {
+		ifExistsExpr = "if exists"
+	}
+	if c.Cascade {
+		cascadeExpr = "cascade"
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated(
+		"drop function", ifExistsExpr, fullName(c.Name)+"("+strings.Join(args, ", ")+")", cascadeExpr,
+	)
+}
+
+func (c *DropFunctionStmt) statement() int { return 0 }
+
+// dependedOn of the drop is the function itself, see DropStmt
+func (c *DropFunctionStmt) dependedOn() Dependencies {
+	if c.IfExists {
+		return nil
+	}
+	return objectDependencies(c.Name)
+}
+
+func (c *DropFunctionStmt) solved() (result Dependencies) {
+	return nil
+}
+
+func (c *DropFunctionStmt) unsolved() Dependencies {
+	return objectDependencies(c.Name)
+}
+
+type (
+	// GrantStmt grants all privileges if Privileges is empty or contains `all`
+	GrantStmt struct {
+		Privileges      []string
+		Target          SqlTarget
+		Objects         []GrantObject
+		Grantees        []string
+		WithGrantOption bool
+	}
+	// GrantObject is the object of the grant, ArgTypes is the signature of the function for TargetFunction
+	GrantObject struct {
+		Name     SqlIdent
+		ArgTypes []*DataTypeExpr
+	}
+)
+
+func (c *GrantStmt) String() string {
+	var (
+		privileges  = "all privileges"
+		objects     = make([]string, 0, len(c.Objects))
+		grantOption string
+	)
+	if len(c.Privileges) > 0 && !utils.ArrayContainsCI(c.Privileges, "all") {
+		privileges = strings.ToLower(strings.Join(c.Privileges, ", "))
+	}
+	for _, object := range c.Objects {
+		var name = fullName(object.Name)
+		if c.Target == TargetFunction {
+			var args = make([]string, 0, len(object.ArgTypes))
+			for _, arg := range object.ArgTypes {
+				args = append(args, arg.String())
+			}
+			name += "(" + strings.Join(args, ", ") + ")"
+		}
+		objects = append(objects, name)
+	}
+	if c.WithGrantOption {
+		grantOption = "with grant option"
+	}
+	return utils.NonEmptyStringsConcatSpaceSeparated(
+		"grant", privileges, "on", c.Target, strings.Join(objects, ", "),
+		"to", strings.Join(c.Grantees, ", "), grantOption,
+	)
+}
+
+func (c *GrantStmt) statement() int { return 0 }
+
+func (c *GrantStmt) dependedOn() Dependencies {
+	var result Dependencies
+	for _, object := range c.Objects {
+		if c.Target == TargetSchema {
+			result = concatDependencies(result, dependedOn2(object.Name.GetName(), ""))
+			continue
+		}
+		result = concatDependencies(result, objectDependencies(object.Name))
+	}
+	return result
+}
+
+func (c *GrantStmt) solved() (result Dependencies) {
+	return nil
+}
+
+func (c *GrantStmt) unsolved() (result Dependencies) {
+	return nil
 }
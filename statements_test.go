@@ -0,0 +1,772 @@
+package sql_ast
+
+import (
+	"go/token"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAlterStmt_unsolved(t *testing.T) {
+	var tests = []struct {
+		name     string
+		stmt     *AlterStmt
+		expected Dependencies
+	}{
+		{
+			name: "add column to unqualified table",
+			stmt: &AlterStmt{Target: TargetTable, Name: &Literal{Text: "t"}, Alter: &AddColumnExpr{
+				Column: &SqlField{Name: &Literal{Text: "c"}, Describer: &DataTypeExpr{DataType: "text"}},
+			}},
+		},
+		{
+			name: "set schema of unqualified table",
+			stmt: &AlterStmt{Target: TargetTable, Name: &Literal{Text: "t"}, Alter: &SetExpr{Set: &SchemaExpr{SchemaName: "s"}}},
+		},
+		{
+			name:     "drop column of unqualified table",
+			stmt:     &AlterStmt{Target: TargetTable, Name: &Literal{Text: "t"}, Alter: &DropColumnExpr{Column: &Literal{Text: "c"}}},
+			expected: Dependencies{{Object: "t", Field: "c"}},
+		},
+		{
+			name:     "drop constraint",
+			stmt:     &AlterStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: "t"}, Alter: &DropExpr{Target: TargetConstraint, Name: &Literal{Text: "t_pk"}}},
+			expected: Dependencies{{Schema: "s", Object: "t", Field: "t_pk"}},
+		},
+		{
+			name:     "rename table",
+			stmt:     &AlterStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: "t"}, Alter: &SqlRename{NewName: &Literal{Text: "n"}}},
+			expected: Dependencies{{Schema: "s", Object: "t"}},
+		},
+		{
+			name:     "rename column",
+			stmt:     &AlterStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: "t"}, Alter: &SqlRename{Target: TargetColumn, OldName: &Literal{Text: "a"}, NewName: &Literal{Text: "b"}}},
+			expected: Dependencies{{Schema: "s", Object: "t", Field: "a"}},
+		},
+		{
+			name:     "rename schema",
+			stmt:     &AlterStmt{Target: TargetSchema, Name: &Literal{Text: "s"}, Alter: &SqlRename{NewName: &Literal{Text: "n"}}},
+			expected: Dependencies{{Schema: "s"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.stmt.unsolved(); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("unsolved() = %v, expected %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestDropStmt_dependencies(t *testing.T) {
+	var tests = []struct {
+		name       string
+		stmt       SqlStmt
+		expected   string
+		dependedOn Dependencies
+		unsolved   Dependencies
+	}{
+		{
+			name:       "drop table",
+			stmt:       &DropStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: "t"}},
+			expected:   "drop table s.t",
+			dependedOn: Dependencies{{Schema: "s", Object: "t"}},
+			unsolved:   Dependencies{{Schema: "s", Object: "t"}},
+		},
+		{
+			name:     "drop table if exists",
+			stmt:     &DropStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: "t"}, IfExists: true},
+			expected: "drop table if exists s.t",
+			unsolved: Dependencies{{Schema: "s", Object: "t"}},
+		},
+		{
+			name:       "drop schema",
+			stmt:       &DropStmt{Target: TargetSchema, Name: &Literal{Text: "s"}},
+			expected:   "drop schema s",
+			dependedOn: Dependencies{{Schema: "s"}},
+			unsolved:   Dependencies{{Schema: "s"}},
+		},
+		{
+			name:     "drop function if exists",
+			stmt:     &DropFunctionStmt{Name: &Selector{Container: "s", Name: "f"}, IfExists: true},
+			expected: "drop function if exists s.f()",
+			unsolved: Dependencies{{Schema: "s", Object: "f"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.stmt.String(); actual != test.expected {
+				t.Errorf("String() = %q, expected %q", actual, test.expected)
+			}
+			if actual := test.stmt.dependedOn(); !reflect.DeepEqual(actual, test.dependedOn) {
+				t.Errorf("dependedOn() = %v, expected %v", actual, test.dependedOn)
+			}
+			if actual := test.stmt.unsolved(); !reflect.DeepEqual(actual, test.unsolved) {
+				t.Errorf("unsolved() = %v, expected %v", actual, test.unsolved)
+			}
+		})
+	}
+}
+
+func TestResolveNames_dropIfExists(t *testing.T) {
+	var drop = &DropStmt{Target: TargetTable, Name: &Literal{Text: "t"}}
+	if err := ResolveNames([]SqlStmt{drop}, "public"); err == nil {
+		t.Error("the dropped table has to be resolved")
+	}
+	drop.IfExists = true
+	if err := ResolveNames([]SqlStmt{drop}, "public"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestInsertStmt_dependedOn(t *testing.T) {
+	var tests = []struct {
+		name     string
+		stmt     *InsertStmt
+		expected Dependencies
+	}{
+		{
+			name:     "constants",
+			stmt:     (&InsertStmt{Table: QualifiedTable("s", "t"), Columns: []string{"a"}}).AddRow(&Integer{X: 1}),
+			expected: Dependencies{{Schema: "s", Object: "t"}},
+		},
+		{
+			name: "sequence and subquery",
+			stmt: (&InsertStmt{Table: QualifiedTable("s", "t"), Columns: []string{"a", "b"}}).AddRow(
+				&FncCall{Name: &Literal{Text: "nextval"}, Args: []SqlExpr{&String{X: "s.seq"}}},
+				&BracketBlock{Statement: &SelectStmt{Columns: []SqlExpr{&Literal{Text: "b"}}, From: QualifiedTable("s", "u")}},
+			),
+			expected: Dependencies{{Schema: "s", Object: "t"}, {Schema: "s", Object: "seq"}, {Schema: "s", Object: "u"}},
+		},
+		{
+			name:     "select query",
+			stmt:     &InsertStmt{Table: QualifiedTable("s", "t"), SelectQuery: &SelectStmt{Columns: []SqlExpr{&Literal{Text: "b"}}, From: QualifiedTable("s", "u")}},
+			expected: Dependencies{{Schema: "s", Object: "t"}, {Schema: "s", Object: "u"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.stmt.dependedOn(); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("dependedOn() = %v, expected %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestAlterStmt_setSchema(t *testing.T) {
+	var (
+		alter = &AlterStmt{Target: TargetTable, Name: &Selector{Container: "old", Name: "t"}, Alter: &SetExpr{Set: &SchemaExpr{SchemaName: "new"}}}
+		index = &CreateIndexStmt{Name: &Literal{Text: "t_c_idx"}, Table: &Selector{Container: "new", Name: "t"}, Keys: []IndexKey{&ColumnIndexKey{Column: &Literal{Text: "c"}}}}
+		table = &CreateStmt{Target: TargetTable, Name: &Selector{Container: "old", Name: "t"}, Create: (&TableBodyDescriber{}).AddField(
+			&SqlField{Name: &Literal{Text: "c"}, Describer: &DataTypeExpr{DataType: "text"}},
+		)}
+		schema = &CreateStmt{Target: TargetSchema, Name: &Literal{Text: "new"}}
+	)
+	if actual := alter.String(); actual != "alter table old.t set schema new" {
+		t.Errorf("unexpected alter: %s", actual)
+	}
+	if expected, actual := (Dependencies{{Schema: "new", Object: "t"}}), alter.solved(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("solved() = %v, expected %v", actual, expected)
+	}
+	if expected, actual := (Dependencies{{Schema: "old", Object: "t"}, {Schema: "new"}}), alter.dependedOn(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("dependedOn() = %v, expected %v", actual, expected)
+	}
+	sorted, err := TopologicalSort([]SqlStmt{index, alter, schema, table})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expected = StatementList{schema, table, alter, index}
+	if !reflect.DeepEqual(sorted, expected) {
+		t.Errorf("unexpected order:\n%s\nexpected:\n%s", sorted, expected)
+	}
+}
+
+func TestJoinClause(t *testing.T) {
+	var on = &BinaryExpr{
+		Left:  &ColumnRef{Table: &Literal{Text: "a"}, Column: &Literal{Text: "id"}},
+		Right: &ColumnRef{Table: &Literal{Text: "b"}, Column: &Literal{Text: "id"}},
+		Op:    token.EQL,
+	}
+	var tests = []struct {
+		name     string
+		join     JoinClause
+		expected string
+		err      string
+	}{
+		{name: "cross", join: JoinClause{Kind: "cross", Table: Table("b")}, expected: "cross join b"},
+		{name: "natural", join: JoinClause{Kind: "natural", Table: Table("b")}, expected: "natural join b"},
+		{name: "natural left", join: JoinClause{Kind: "NATURAL LEFT", Table: Table("b")}, expected: "natural left join b"},
+		{name: "inner on", join: JoinClause{Kind: "inner", Table: Table("b"), On: on}, expected: "inner join b on a.id == b.id"},
+		{name: "left using", join: JoinClause{Kind: "left", Table: Table("b"), Using: []string{"id", "code"}}, expected: "left join b using (id, code)"},
+		{name: "cross with condition", join: JoinClause{Kind: "cross", Table: Table("b"), On: on}, err: "cross join cannot have a condition"},
+		{name: "natural with condition", join: JoinClause{Kind: "natural inner", Table: Table("b"), Using: []string{"id"}}, err: "natural inner join cannot have a condition"},
+		{name: "inner without condition", join: JoinClause{Kind: "inner", Table: Table("b")}, err: "inner join requires a condition"},
+		{name: "on and using", join: JoinClause{Kind: "inner", Table: Table("b"), On: on, Using: []string{"id"}}, err: "join allows just On or Using not both"},
+		{name: "unknown kind", join: JoinClause{Kind: "outer", Table: Table("b"), On: on}, err: "unknown join kind `outer`"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var err = test.join.Validate()
+			if test.err != "" {
+				if err == nil || err.Error() != test.err {
+					t.Errorf("Validate() = %v, expected %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if actual := test.join.String(); actual != test.expected {
+				t.Errorf("String() = %q, expected %q", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestCreateIndexStmt_partial(t *testing.T) {
+	var (
+		column = func(name string) SqlExpr { return &ColumnRef{Column: &Literal{Text: name}} }
+		index  = &CreateIndexStmt{
+			Name:  &Literal{Text: "users_email_idx"},
+			Table: &Selector{Container: "s", Name: "users"},
+			Keys:  []IndexKey{&ExprIndexKey{Expr: &FncCall{Name: &Literal{Text: "lower"}, Args: []SqlExpr{column("email")}}}},
+			Where: &BinaryExpr{
+				Left:  &BinaryExpr{Left: column("is_active"), Right: &True{}, Op: token.EQL},
+				Right: &BinaryExpr{Left: column("tenant"), Right: &FncCall{Name: &Selector{Container: "s", Name: "current_tenant"}}, Op: token.EQL},
+				Op:    token.LAND,
+			},
+		}
+	)
+	const expected = "create index users_email_idx on s.users ((lower(email))) where is_active == true && tenant == s.current_tenant()"
+	if actual := index.String(); actual != expected {
+		t.Errorf("String() = %q, expected %q", actual, expected)
+	}
+	var dependencies = Dependencies{
+		{Schema: "s", Object: "users"},
+		{Schema: "s", Object: "users", Field: "email"},
+		{Schema: "s", Object: "users", Field: "is_active"},
+		{Schema: "s", Object: "users", Field: "tenant"},
+		{Schema: "s", Object: "current_tenant"},
+	}
+	if actual := index.dependedOn(); !reflect.DeepEqual(actual, dependencies) {
+		t.Errorf("dependedOn() = %v, expected %v", actual, dependencies)
+	}
+}
+
+func TestCreateIndexStmt_opClass(t *testing.T) {
+	var tests = []struct {
+		name       string
+		index      *CreateIndexStmt
+		expected   string
+		dependedOn Dependencies
+	}{
+		{
+			name: "gin trigram",
+			index: &CreateIndexStmt{
+				Name:  &Literal{Text: "t_name_trgm_idx"},
+				Table: &Selector{Container: "s", Name: "t"},
+				Using: "GIN",
+				Keys:  []IndexKey{&ColumnIndexKey{Column: &Literal{Text: "name"}, OpClass: &Selector{Container: "ext", Name: "gin_trgm_ops"}}},
+			},
+			expected: "create index t_name_trgm_idx on s.t using gin (name ext.gin_trgm_ops)",
+			dependedOn: Dependencies{
+				{Schema: "s", Object: "t"},
+				{Schema: "s", Object: "t", Field: "name"},
+				{Schema: "ext", Object: "gin_trgm_ops"},
+			},
+		},
+		{
+			name: "gin trigram expression",
+			index: &CreateIndexStmt{
+				Table: &Selector{Container: "s", Name: "t"},
+				Using: "gin",
+				Keys: []IndexKey{&ExprIndexKey{
+					Expr:    &FncCall{Name: &Literal{Text: "lower"}, Args: []SqlExpr{&ColumnRef{Column: &Literal{Text: "name"}}}},
+					OpClass: &Literal{Text: "gin_trgm_ops"},
+				}},
+			},
+			expected: "create index on s.t using gin ((lower(name)) gin_trgm_ops)",
+			dependedOn: Dependencies{
+				{Schema: "s", Object: "t"},
+				{Schema: "s", Object: "t", Field: "name"},
+			},
+		},
+		{
+			name: "brin",
+			index: &CreateIndexStmt{
+				Name:  &Literal{Text: "t_created_idx"},
+				Table: &Selector{Container: "s", Name: "t"},
+				Using: "brin",
+				Keys:  []IndexKey{&ColumnIndexKey{Column: &Literal{Text: "created"}, OpClass: &Selector{Container: "pg_catalog", Name: "timestamp_minmax_ops"}}},
+			},
+			expected: "create index t_created_idx on s.t using brin (created pg_catalog.timestamp_minmax_ops)",
+			dependedOn: Dependencies{
+				{Schema: "s", Object: "t"},
+				{Schema: "s", Object: "t", Field: "created"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.index.String(); actual != test.expected {
+				t.Errorf("String() = %q, expected %q", actual, test.expected)
+			}
+			if actual := test.index.dependedOn(); !reflect.DeepEqual(actual, test.dependedOn) {
+				t.Errorf("dependedOn() = %v, expected %v", actual, test.dependedOn)
+			}
+		})
+	}
+}
+
+func TestSelectStmt_String(t *testing.T) {
+	var (
+		a     = &Literal{Text: "a"}
+		where = &BinaryExpr{Left: a, Right: &Integer{X: 1}, Op: token.EQL}
+	)
+	var tests = []struct {
+		name     string
+		stmt     *SelectStmt
+		expected string
+	}{
+		{name: "valueless", stmt: &SelectStmt{Columns: []SqlExpr{&FncCall{Name: &Literal{Text: "now"}}}}, expected: "select now()"},
+		{name: "no columns", stmt: &SelectStmt{From: Table("t")}, expected: "select from t where 1 = 1"},
+		{name: "no alias", stmt: &SelectStmt{Columns: []SqlExpr{a}, From: Table("t")}, expected: "select a from t where 1 = 1"},
+		{name: "empty alias", stmt: &SelectStmt{Columns: []SqlExpr{a}, From: TableDesc{Table: &Literal{Text: "t"}, Alias: ""}, Where: where}, expected: "select a from t where a == 1"},
+		{name: "alias", stmt: &SelectStmt{Columns: []SqlExpr{a}, From: Table("t").WithAlias("x"), Where: where}, expected: "select a from t x where a == 1"},
+		{name: "no from", stmt: &SelectStmt{Columns: []SqlExpr{a}, Where: where}, expected: "select a where a == 1"},
+		{name: "empty joins and windows", stmt: &SelectStmt{Columns: []SqlExpr{a}, From: Table("t"), Joins: []JoinClause{}, Windows: []WindowDef{}}, expected: "select a from t where 1 = 1"},
+		{name: "join", stmt: &SelectStmt{Columns: []SqlExpr{a}, From: Table("t"), Joins: []JoinClause{{Kind: "cross", Table: Table("u")}}}, expected: "select a from t cross join u where 1 = 1"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var actual = test.stmt.String()
+			if actual != test.expected {
+				t.Errorf("String() = %q, expected %q", actual, test.expected)
+			}
+			if strings.Contains(actual, "  ") || strings.TrimSpace(actual) != actual {
+				t.Errorf("unexpected spaces in %q", actual)
+			}
+		})
+	}
+}
+
+func TestSelectStmt_dependedOnAliases(t *testing.T) {
+	var (
+		column = func(table, name string) SqlExpr {
+			return &ColumnRef{Table: &Literal{Text: table}, Column: &Literal{Text: name}}
+		}
+		equal = func(left, right SqlExpr) SqlExpr {
+			return &BinaryExpr{Left: left, Right: right, Op: token.EQL}
+		}
+	)
+	var tests = []struct {
+		name     string
+		stmt     SqlStmt
+		expected Dependencies
+	}{
+		{
+			name:     "alias",
+			stmt:     &SelectStmt{Columns: []SqlExpr{column("u", "id")}, From: QualifiedTable("s", "users").WithAlias("u")},
+			expected: Dependencies{{Schema: "s", Object: "users"}, {Schema: "s", Object: "users", Field: "id"}},
+		},
+		{
+			name:     "table without schema",
+			stmt:     &SelectStmt{Columns: []SqlExpr{column("users", "id")}, From: QualifiedTable("s", "users")},
+			expected: Dependencies{{Schema: "s", Object: "users"}, {Schema: "s", Object: "users", Field: "id"}},
+		},
+		{
+			name: "subquery",
+			stmt: &SelectStmt{
+				Columns: []SqlExpr{column("q", "id")},
+				From:    TableDesc{Query: &BracketBlock{Statement: &SelectStmt{Columns: []SqlExpr{&Literal{Text: "id"}}, From: QualifiedTable("s", "users")}}, Alias: "q"},
+			},
+			expected: Dependencies{{Schema: "s", Object: "users"}},
+		},
+		{
+			name: "join",
+			stmt: &SelectStmt{
+				Columns: []SqlExpr{column("o", "id")},
+				From:    QualifiedTable("s", "users").WithAlias("u"),
+				Joins:   []JoinClause{{Kind: "inner", Table: QualifiedTable("s", "orders").WithAlias("o"), On: equal(column("o", "user_id"), column("u", "id"))}},
+			},
+			expected: Dependencies{
+				{Schema: "s", Object: "users"},
+				{Schema: "s", Object: "orders"},
+				{Schema: "s", Object: "orders", Field: "user_id"},
+				{Schema: "s", Object: "users", Field: "id"},
+				{Schema: "s", Object: "orders", Field: "id"},
+			},
+		},
+		{
+			name: "correlated subquery",
+			stmt: &SelectStmt{
+				Columns: []SqlExpr{column("a", "x")},
+				From:    QualifiedTable("s", "a").WithAlias("a"),
+				Where: &FncCall{Name: &Literal{Text: "exists"}, Args: []SqlExpr{&BracketBlock{Statement: &SelectStmt{
+					Columns: []SqlExpr{&Integer{X: 1}},
+					From:    QualifiedTable("s", "b").WithAlias("b"),
+					Where:   equal(column("b", "y"), column("a", "x")),
+				}}}},
+			},
+			expected: Dependencies{
+				{Schema: "s", Object: "a"},
+				{Schema: "s", Object: "a", Field: "x"},
+				{Schema: "s", Object: "b"},
+				{Schema: "s", Object: "b", Field: "y"},
+				{Schema: "s", Object: "a", Field: "x"},
+			},
+		},
+		{
+			name: "update",
+			stmt: &UpdateStmt{
+				Table: QualifiedTable("s", "users").WithAlias("u"),
+				Set:   []SqlExpr{equal(&Literal{Text: "name"}, column("o", "name"))},
+				From:  []TableDesc{QualifiedTable("s", "orders").WithAlias("o")},
+			},
+			expected: Dependencies{{Schema: "s", Object: "orders", Field: "name"}, {Schema: "s", Object: "orders"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.stmt.dependedOn(); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("dependedOn() = %v, expected %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestAlterStmt_dependedOnUnqualified(t *testing.T) {
+	var table = &Literal{Text: "t"}
+	var tests = []struct {
+		name     string
+		stmt     *AlterStmt
+		expected Dependencies
+	}{
+		{
+			name: "add constraint",
+			stmt: &AlterStmt{Target: TargetTable, Name: table, Alter: &AddExpr{Target: TargetConstraint, Name: &Literal{Text: "t_pk"}, Definition: &ConstraintWithColumns{
+				Columns: []string{"id"}, Constraint: &UnnamedConstraintExpr{Constraint: &ConstraintPrimaryKeyExpr{}},
+			}}},
+			expected: Dependencies{{Object: "t"}},
+		},
+		{
+			name:     "add value",
+			stmt:     &AlterStmt{Target: TargetType, Name: &Literal{Text: "mood"}, Alter: &AddValueExpr{Value: "ok"}},
+			expected: Dependencies{{Object: "mood"}},
+		},
+		{
+			name:     "set schema",
+			stmt:     &AlterStmt{Target: TargetTable, Name: table, Alter: &SetExpr{Set: &SchemaExpr{SchemaName: "s"}}},
+			expected: Dependencies{{Object: "t"}, {Schema: "s"}},
+		},
+		{
+			name:     "rename constraint",
+			stmt:     &AlterStmt{Target: TargetTable, Name: table, Alter: &SqlRename{Target: TargetConstraint, OldName: &Literal{Text: "a"}, NewName: &Literal{Text: "b"}}},
+			expected: Dependencies{{Object: "t", Field: "a"}},
+		},
+		{
+			name:     "cluster on",
+			stmt:     &AlterStmt{Target: TargetTable, Name: table, Alter: &ClusterOnExpr{IndexName: &Literal{Text: "t_idx"}}},
+			expected: Dependencies{{Object: "t"}, {Object: "t_idx"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := ExploreDependencies(test.stmt); !reflect.DeepEqual(actual, test.expected) {
+				t.Errorf("ExploreDependencies() = %v, expected %v", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestTablespace(t *testing.T) {
+	var (
+		body = func(tablespace SqlIdent) *TableBodyDescriber {
+			return &TableBodyDescriber{
+				Fields:     []*SqlField{{Name: &Literal{Text: "a"}, Describer: &DataTypeExpr{DataType: "integer"}}},
+				Tablespace: tablespace,
+			}
+		}
+		fast = &Literal{Text: "fast"}
+	)
+	var tests = []struct {
+		name       string
+		stmt       SqlStmt
+		expected   string
+		dependedOn Dependencies
+	}{
+		{
+			name:       "table",
+			stmt:       &CreateStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: "t"}, Create: body(nil), Tablespace: fast},
+			expected:   "create table s.t (\n\ta integer\n) tablespace fast",
+			dependedOn: Dependencies{{Object: "fast"}},
+		},
+		{
+			name:       "table body",
+			stmt:       &CreateStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: "t"}, Create: body(fast)},
+			expected:   "create table s.t (\n\ta integer\n) tablespace fast",
+			dependedOn: Dependencies{{Object: "fast"}},
+		},
+		{
+			name: "index",
+			stmt: &CreateIndexStmt{
+				Name:       &Literal{Text: "t_a_idx"},
+				Table:      &Selector{Container: "s", Name: "t"},
+				Keys:       []IndexKey{&ColumnIndexKey{Column: &Literal{Text: "a"}}},
+				Tablespace: fast,
+				Where:      &BinaryExpr{Left: &Literal{Text: "a"}, Right: &Integer{X: 0}, Op: token.GTR},
+			},
+			expected: "create index t_a_idx on s.t (a) tablespace fast where a > 0",
+			dependedOn: Dependencies{
+				{Schema: "s", Object: "t"},
+				{Schema: "s", Object: "t", Field: "a"},
+				{Object: "fast"},
+			},
+		},
+		{
+			name: "built-in index tablespace",
+			stmt: &CreateIndexStmt{
+				Table:      &Selector{Container: "s", Name: "t"},
+				Keys:       []IndexKey{&ColumnIndexKey{Column: &Literal{Text: "a"}}},
+				Tablespace: &Literal{Text: "pg_default"},
+			},
+			expected:   "create index on s.t (a) tablespace pg_default",
+			dependedOn: Dependencies{{Schema: "s", Object: "t"}, {Schema: "s", Object: "t", Field: "a"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.stmt.String(); actual != test.expected {
+				t.Errorf("String() = %q, expected %q", actual, test.expected)
+			}
+			if actual := test.stmt.dependedOn(); !reflect.DeepEqual(actual, test.dependedOn) {
+				t.Errorf("dependedOn() = %v, expected %v", actual, test.dependedOn)
+			}
+		})
+	}
+	t.Run("both", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("the tablespace of the statement and of the table body cannot be set both")
+			}
+		}()
+		_ = (&CreateStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: "t"}, Create: body(fast), Tablespace: fast}).String()
+	})
+}
+
+func TestUpdateStmt_joins(t *testing.T) {
+	var (
+		column = func(table SqlIdent, name string) SqlExpr {
+			return &ColumnRef{Table: table, Column: &Literal{Text: name}}
+		}
+		equal = func(left, right SqlExpr) SqlExpr {
+			return &BinaryExpr{Left: left, Right: right, Op: token.EQL}
+		}
+		s1 = &Literal{Text: "s1"}
+		s2 = &Literal{Text: "s2"}
+	)
+	var tests = []struct {
+		name       string
+		stmt       *UpdateStmt
+		expected   string
+		dependedOn Dependencies
+	}{
+		{
+			name:       "where",
+			stmt:       &UpdateStmt{Table: QualifiedTable("s", "u"), Set: []SqlExpr{equal(&Literal{Text: "x"}, &Integer{X: 2})}, Where: equal(column(&Selector{Container: "s", Name: "u"}, "x"), &Integer{X: 1})},
+			expected:   "update s.u set x == 2 where s.u.x == 1",
+			dependedOn: Dependencies{{Schema: "s", Object: "u", Field: "x"}},
+		},
+		{
+			name: "from join",
+			stmt: &UpdateStmt{
+				Table: QualifiedTable("s", "u"),
+				Set:   []SqlExpr{equal(&Literal{Text: "x"}, column(s2, "x"))},
+				From:  []TableDesc{QualifiedTable("s", "a").WithAlias("s1")},
+				Joins: []JoinClause{{Kind: "inner", Table: QualifiedTable("s", "b").WithAlias("s2"), On: equal(column(s2, "a_id"), column(s1, "id"))}},
+				Where: equal(column(&Selector{Container: "s", Name: "u"}, "id"), column(s1, "u_id")),
+			},
+			expected: "update s.u set x == s2.x from s.a s1 inner join s.b s2 on s2.a_id == s1.id where s.u.id == s1.u_id",
+			dependedOn: Dependencies{
+				{Schema: "s", Object: "b", Field: "x"},
+				{Schema: "s", Object: "a"},
+				{Schema: "s", Object: "b"},
+				{Schema: "s", Object: "b", Field: "a_id"},
+				{Schema: "s", Object: "a", Field: "id"},
+				{Schema: "s", Object: "u", Field: "id"},
+				{Schema: "s", Object: "a", Field: "u_id"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.stmt.String(); actual != test.expected {
+				t.Errorf("String() = %q, expected %q", actual, test.expected)
+			}
+			if actual := test.stmt.dependedOn(); !reflect.DeepEqual(actual, test.dependedOn) {
+				t.Errorf("dependedOn() = %v, expected %v", actual, test.dependedOn)
+			}
+		})
+	}
+	t.Run("joins without from", func(t *testing.T) {
+		defer func() {
+			if recover() == nil {
+				t.Error("the joins require the FROM clause")
+			}
+		}()
+		_ = (&UpdateStmt{Table: QualifiedTable("s", "u"), Joins: []JoinClause{{Kind: "cross", Table: Table("b")}}}).String()
+	})
+}
+
+func TestInsertStmt_String(t *testing.T) {
+	var tests = []struct {
+		name     string
+		stmt     *InsertStmt
+		expected string
+		panics   bool
+	}{
+		{
+			name:     "rows",
+			stmt:     (&InsertStmt{Table: QualifiedTable("s", "t"), Columns: []string{"a", "b"}}).AddRow(&Integer{X: 1}, &String{X: "x"}).AddRow(&Integer{X: 2}, &String{X: "y"}),
+			expected: "insert into s.t (a, b) values (1, 'x'), (2, 'y')",
+		},
+		{
+			name:     "without columns",
+			stmt:     (&InsertStmt{Table: QualifiedTable("s", "t")}).AddRow(&Integer{X: 1}),
+			expected: "insert into s.t values (1)",
+		},
+		{
+			name:     "select query",
+			stmt:     &InsertStmt{Table: QualifiedTable("s", "t"), Columns: []string{"a"}, SelectQuery: &SelectStmt{Columns: []SqlExpr{&Literal{Text: "a"}}, From: QualifiedTable("s", "u")}},
+			expected: "insert into s.t (a) select a from s.u where 1 = 1",
+		},
+		{name: "no rows", stmt: &InsertStmt{Table: QualifiedTable("s", "t"), Columns: []string{"a"}}, panics: true},
+		{name: "short row", stmt: (&InsertStmt{Table: QualifiedTable("s", "t"), Columns: []string{"a", "b"}}).AddRow(&Integer{X: 1}), panics: true},
+		{name: "rows of different width", stmt: (&InsertStmt{Table: QualifiedTable("s", "t")}).AddRow(&Integer{X: 1}).AddRow(&Integer{X: 1}, &Integer{X: 2}), panics: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != test.panics {
+					t.Errorf("panic: %v, expected %v", r, test.panics)
+				}
+			}()
+			if actual := test.stmt.String(); actual != test.expected {
+				t.Errorf("String() = %q, expected %q", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestInsertStmt_AddColumn(t *testing.T) {
+	var stmt = (&InsertStmt{Table: QualifiedTable("s", "t"), Columns: []string{"a"}}).AddRow(&Integer{X: 1}).AddRow(&Integer{X: 2})
+	stmt.AddColumn("b", &FncCall{Name: &Literal{Text: "now"}})
+	stmt.Values[1][1].(*FncCall).Name = &Literal{Text: "clock_timestamp"}
+	if actual := stmt.String(); actual != "insert into s.t (a, b) values (1, now()), (2, clock_timestamp())" {
+		t.Errorf("the rows have to get their own values: %s", actual)
+	}
+}
+
+func TestGrantStmt_String(t *testing.T) {
+	var integer = &DataTypeExpr{DataType: "int"}
+	var tests = []struct {
+		name     string
+		stmt     *GrantStmt
+		expected string
+	}{
+		{
+			name: "table with grant option",
+			stmt: &GrantStmt{
+				Privileges:      []string{"SELECT", "INSERT"},
+				Target:          TargetTable,
+				Objects:         []GrantObject{{Name: &Selector{Container: "schema", Name: "t"}}},
+				Grantees:        []string{"role1", "role2"},
+				WithGrantOption: true,
+			},
+			expected: "grant select, insert on table schema.t to role1, role2 with grant option",
+		},
+		{
+			name:     "function",
+			stmt:     &GrantStmt{Privileges: []string{"EXECUTE"}, Target: TargetFunction, Objects: []GrantObject{{Name: &Literal{Text: "f"}, ArgTypes: []*DataTypeExpr{integer}}}, Grantees: []string{"public"}},
+			expected: "grant execute on function f(int) to public",
+		},
+		{
+			name: "overloaded functions",
+			stmt: &GrantStmt{Privileges: []string{"execute"}, Target: TargetFunction, Objects: []GrantObject{
+				{Name: &Selector{Container: "s", Name: "f"}, ArgTypes: []*DataTypeExpr{integer}},
+				{Name: &Selector{Container: "s", Name: "f"}, ArgTypes: []*DataTypeExpr{integer, {DataType: "text"}}},
+				{Name: &Selector{Container: "s", Name: "g"}},
+			}, Grantees: []string{"u"}},
+			expected: "grant execute on function s.f(int), s.f(int, text), s.g() to u",
+		},
+		{
+			name:     "all privileges",
+			stmt:     &GrantStmt{Target: TargetSchema, Objects: []GrantObject{{Name: &Literal{Text: "s"}}}, Grantees: []string{"u"}},
+			expected: "grant all privileges on schema s to u",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := test.stmt.String(); actual != test.expected {
+				t.Errorf("String() = %q, expected %q", actual, test.expected)
+			}
+		})
+	}
+}
+
+func TestDependencyFrom(t *testing.T) {
+	var table = &CreateStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: "t"}, Create: &TableBodyDescriber{
+		Fields: []*SqlField{
+			{Name: &Literal{Text: "id"}, Describer: &DataTypeExpr{DataType: "integer"}},
+			{Name: &Literal{Text: "code"}, Describer: &DataTypeExpr{DataType: "text"}},
+		},
+		Constraints: []ConstraintExpr{&NamedConstraintExpr{Name: &Literal{Text: "t_code_uq"}, Constraint: &ConstraintWithColumns{Columns: []string{"code"}, Constraint: &UnnamedConstraintExpr{Constraint: &ConstraintUniqueExpr{}}}}},
+	}}
+	var join = func(dependencies ...Dependencies) (result Dependencies) {
+		for _, d := range dependencies {
+			result = append(result, d...)
+		}
+		return result
+	}
+	var tests = []struct {
+		name     string
+		actual   Dependencies
+		expected Dependencies
+	}{
+		{
+			name:   "create table",
+			actual: table.solved(),
+			expected: join(
+				DependencyFromObject("s", "t"),
+				DependencyFromField("s", "t", "id"),
+				DependencyFromField("s", "t", "code"),
+				DependencyFromField("s", "t", "t_code_uq"),
+			),
+		},
+		{
+			name:     "drop table",
+			actual:   (&DropStmt{Target: TargetTable, Name: &Selector{Container: "s", Name: "t"}}).dependedOn(),
+			expected: DependencyFromObject("s", "t"),
+		},
+		{
+			name:     "drop schema",
+			actual:   (&DropStmt{Target: TargetSchema, Name: &Literal{Text: "s"}}).dependedOn(),
+			expected: DependencyFromObject("s", ""),
+		},
+		{
+			name:   "create index",
+			actual: (&CreateIndexStmt{Name: &Literal{Text: "t_id_idx"}, Table: &Selector{Container: "s", Name: "t"}, Keys: []IndexKey{&ColumnIndexKey{Column: &Literal{Text: "id"}}}}).dependedOn(),
+			expected: join(
+				DependencyFromObject("s", "t"),
+				DependencyFromField("s", "t", "id"),
+			),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !reflect.DeepEqual(test.actual, test.expected) {
+				t.Errorf("dependencies = %v, expected %v", test.actual, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package sql_ast
+
+import (
+	"regexp"
+	"strings"
+)
+
+type (
+	// TypeRegistry tells the built-in types from the user-defined ones, everything not registered is user-defined.
+	// The registry is not a part of the tree, copies of the statements refer to the same registry
+	TypeRegistry struct {
+		Builtin map[string]bool
+	}
+)
+
+var postgresBuiltinTypes = []string{
+	"bigint", "int8", "bigserial", "serial8", "bit", "bit varying", "varbit", "boolean", "bool", "box", "bytea",
+	"character", "char", "character varying", "varchar", "cidr", "circle", "date", "double precision", "float8",
+	"inet", "integer", "int", "int4", "interval", "json", "jsonb", "line", "lseg", "macaddr", "macaddr8", "money",
+	"numeric", "decimal", "path", "pg_lsn", "point", "polygon", "real", "float4", "smallint", "int2", "smallserial",
+	"serial2", "serial", "serial4", "text", "time", "time without time zone", "time with time zone", "timetz",
+	"timestamp", "timestamp without time zone", "timestamp with time zone", "timestamptz", "tsquery", "tsvector",
+	"uuid", "xml", "oid", "name", "regclass", "int4range", "int8range", "numrange", "tsrange", "tstzrange", "daterange",
+}
+
+var mysqlBuiltinTypes = []string{
+	"tinyint", "smallint", "mediumint", "int", "integer", "bigint", "decimal", "dec", "numeric", "fixed", "float",
+	"double", "double precision", "real", "bit", "bool", "boolean", "date", "datetime", "timestamp", "time", "year",
+	"char", "varchar", "binary", "varbinary", "tinyblob", "blob", "mediumblob", "longblob", "tinytext", "text",
+	"mediumtext", "longtext", "enum", "set", "json", "geometry", "point", "linestring", "polygon",
+}
+
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{Builtin: make(map[string]bool)}
+}
+
+// RegisterBuiltinTypes registers the built-in types of the dialect, unknown dialects have none
+func (r *TypeRegistry) RegisterBuiltinTypes(d Dialect) {
+	switch d.(type) {
+	case PostgreSQLDialect, *PostgreSQLDialect:
+		r.RegisterBuiltin(postgresBuiltinTypes...)
+	case MySQLDialect, *MySQLDialect:
+		r.RegisterBuiltin(mysqlBuiltinTypes...)
+	}
+}
+
+func (r *TypeRegistry) RegisterBuiltin(names ...string) {
+	if r.Builtin == nil {
+		r.Builtin = make(map[string]bool)
+	}
+	for _, name := range names {
+		r.Builtin[normalizeTypeName(name)] = true
+	}
+}
+
+func (r *TypeRegistry) IsUserDefined(name string) bool {
+	return !r.Builtin[normalizeTypeName(name)]
+}
+
+// typeDependencies refers to the unqualified user-defined type, the qualified ones are the dependencies of DataTypeExpr.
+// The type is created in a schema, therefore the reference is solved only after it is qualified, see SchemaQualifier
+func (r *TypeRegistry) typeDependencies(dataType *DataTypeExpr) Dependencies {
+	if !r.isUnqualifiedUserDefined(dataType) {
+		return nil
+	}
+	return dependedOn2("", typeName(dataType.DataType))
+}
+
+// qualifyType qualifies the unqualified user-defined type with the schema, the modifiers are kept
+func (r *TypeRegistry) qualifyType(dataType *DataTypeExpr, schema string) {
+	if r.isUnqualifiedUserDefined(dataType) {
+		dataType.DataType = schema + "." + strings.TrimSpace(dataType.DataType)
+	}
+}
+
+func (r *TypeRegistry) isUnqualifiedUserDefined(dataType *DataTypeExpr) bool {
+	return dataType != nil && !strings.Contains(dataType.DataType, ".") && r.IsUserDefined(dataType.DataType)
+}
+
+// typeName cuts off the type modifiers and array brackets keeping the case of the name
+func typeName(name string) string {
+	return strings.Join(strings.Fields(typeModifiers.ReplaceAllString(name, "")), " ")
+}
+
+var typeModifiers = regexp.MustCompile(`\([^)]*\)|\[\d*]`)
+
+// normalizeTypeName cuts off the type modifiers and array brackets: `varchar(255)[]` is `varchar`
+func normalizeTypeName(name string) string {
+	return strings.ToLower(typeName(name))
+}
@@ -0,0 +1,96 @@
+package sql_ast
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newPostgresTypes() *TypeRegistry {
+	var types = NewTypeRegistry()
+	types.RegisterBuiltinTypes(PostgreSQLDialect{})
+	return types
+}
+
+func TestTypeRegistry_IsUserDefined(t *testing.T) {
+	var types = newPostgresTypes()
+	for name, expected := range map[string]bool{
+		"integer":                     false,
+		"TEXT":                        false,
+		"varchar(255)":                false,
+		"timestamp(3) with time zone": false,
+		"double  precision":           false,
+		"int[]":                       false,
+		"mood":                        true,
+		"mood[]":                      true,
+	} {
+		if actual := types.IsUserDefined(name); actual != expected {
+			t.Errorf("IsUserDefined(%q) = %v, expected %v", name, actual, expected)
+		}
+	}
+}
+
+func moodTable(types *TypeRegistry) *CreateStmt {
+	return &CreateStmt{
+		Target: TargetTable,
+		Name:   &Literal{Text: "person"},
+		Create: &TableBodyDescriber{
+			Types: types,
+			Fields: []*SqlField{
+				{Name: &Literal{Text: "id"}, Describer: &DataTypeExpr{DataType: "integer"}},
+				{Name: &Literal{Text: "mood"}, Describer: &DataTypeExpr{DataType: "mood", IsArray: true}},
+			},
+		},
+	}
+}
+
+func TestTableBodyDescriber_typeDependencies(t *testing.T) {
+	var table = moodTable(newPostgresTypes())
+	var expected = Dependencies{{Object: "mood"}}
+	if actual := table.Create.dependedOn(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("dependedOn() = %v, expected %v", actual, expected)
+	}
+	table.Create.(*TableBodyDescriber).Types = nil
+	if actual := table.Create.dependedOn(); len(actual) > 0 {
+		t.Errorf("dependedOn() without registry = %v, expected none", actual)
+	}
+}
+
+func TestTopologicalSort_userDefinedType(t *testing.T) {
+	var (
+		qualifier = SchemaQualifier{DefaultSchema: "public"}
+		table     = qualifier.Qualify(moodTable(newPostgresTypes()))
+		enum      = &CreateStmt{
+			Target: TargetType,
+			Name:   &Selector{Container: "public", Name: "mood"},
+			Create: &EnumDescription{Values: []*String{{X: "sad"}, {X: "happy"}}},
+		}
+	)
+	sorted, err := TopologicalSort([]SqlStmt{table, enum})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sorted[0] != enum || sorted[1] != table {
+		t.Errorf("the type has to be created first, got:\n%s", sorted)
+	}
+	if actual := table.String(); actual != "create table public.person (\n\tid integer,\n\tmood public.mood[]\n)" {
+		t.Errorf("unexpected qualified table:\n%s", actual)
+	}
+}
+
+func TestTypeRegistry_sharedByCopies(t *testing.T) {
+	var (
+		types  = newPostgresTypes()
+		origin = moodTable(types)
+		copied = NewRewriter(origin).RenameTable("", "person", "", "people")[0].(*CreateStmt)
+	)
+	if copied.Create.(*TableBodyDescriber).Types != types {
+		t.Error("the copy has to refer to the same registry")
+	}
+	var expected = Dependencies{{Object: "mood"}}
+	if actual := copied.Create.dependedOn(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("dependedOn() of the copy = %v, expected %v", actual, expected)
+	}
+	if diff := StructuralDiff(origin, cloneNode(origin).(SqlStmt)); diff != "" {
+		t.Errorf("the copy differs:\n%s", diff)
+	}
+}
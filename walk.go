@@ -0,0 +1,150 @@
+package sql_ast
+
+import (
+	"reflect"
+)
+
+// Walk traverses the node in depth-first order and calls visit with a pointer to each nested structure
+// (statements, expressions, TableDesc, etc.) in the order of their fields.
+// If visit returns false, the children of the node are skipped
+func Walk(node interface{}, visit func(node interface{}) bool) {
+	walkValue(reflect.ValueOf(node), visit)
+}
+
+func walkValue(v reflect.Value, visit func(node interface{}) bool) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			walkValue(v.Elem(), visit)
+		}
+	case reflect.Ptr:
+		if v.IsNil() || v.Elem().Kind() != reflect.Struct {
+			return
+		}
+		if visit(v.Interface()) {
+			walkFields(v.Elem(), visit)
+		}
+	case reflect.Struct:
+		if v.CanAddr() {
+			walkValue(v.Addr(), visit)
+		} else if visit(v.Interface()) {
+			walkFields(v, visit)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkValue(v.Index(i), visit)
+		}
+	case reflect.Map:
+		var iter = v.MapRange()
+		for iter.Next() {
+			walkValue(iter.Value(), visit)
+		}
+	}
+}
+
+func walkFields(v reflect.Value, visit func(node interface{}) bool) {
+	for i := 0; i < v.NumField(); i++ {
+		walkValue(v.Field(i), visit)
+	}
+}
+
+// typeRegistryType is not cloned, the registry is shared by the copies
+var typeRegistryType = reflect.TypeOf((*TypeRegistry)(nil))
+
+// cloneNode makes a deep copy of the node, so that it can be modified without affecting the origin
+func cloneNode(node interface{}) interface{} {
+	if node == nil {
+		return nil
+	}
+	return cloneValue(reflect.ValueOf(node)).Interface()
+}
+
+func cloneValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() || v.Type() == typeRegistryType {
+			return v
+		}
+		var c = reflect.New(v.Elem().Type())
+		c.Elem().Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		var c = reflect.New(v.Type()).Elem()
+		c.Set(cloneValue(v.Elem()))
+		return c
+	case reflect.Struct:
+		var c = reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			if c.Field(i).CanSet() {
+				c.Field(i).Set(cloneValue(v.Field(i)))
+			}
+		}
+		return c
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		var c = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return c
+	case reflect.Array:
+		var c = reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			c.Index(i).Set(cloneValue(v.Index(i)))
+		}
+		return c
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		var (
+			c    = reflect.MakeMapWithSize(v.Type(), v.Len())
+			iter = v.MapRange()
+		)
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), cloneValue(iter.Value()))
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// replaceNodes traverses the node in post-order and replaces the nested nodes stored in interface fields
+// with the result of replace, nil result keeps the node as is
+func replaceNodes(node interface{}, replace func(node interface{}) interface{}) {
+	replaceValue(reflect.ValueOf(node), replace)
+}
+
+func replaceValue(v reflect.Value, replace func(node interface{}) interface{}) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		replaceValue(v.Elem(), replace)
+		if !v.CanSet() {
+			return
+		}
+		if r := replace(v.Elem().Interface()); r != nil && reflect.TypeOf(r).AssignableTo(v.Type()) {
+			v.Set(reflect.ValueOf(r))
+		}
+	case reflect.Ptr:
+		if !v.IsNil() && v.Elem().Kind() == reflect.Struct {
+			replaceValue(v.Elem(), replace)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			replaceValue(v.Field(i), replace)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			replaceValue(v.Index(i), replace)
+		}
+	}
+}